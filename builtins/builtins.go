@@ -2,6 +2,7 @@ package builtins
 
 import (
 	"fmt"
+	"unicode/utf8"
 	"wtf-script/types"
 )
 
@@ -9,6 +10,8 @@ const (
 	PRINT  = "print"
 	SEED   = "seed"
 	TYPEOF = "typeof"
+	CALL   = "call"
+	LEN    = "len"
 )
 
 func RegisterBuiltins(register func(name string, fn types.IBuiltinFunc)) {
@@ -19,14 +22,7 @@ func RegisterBuiltins(register func(name string, fn types.IBuiltinFunc)) {
 		}
 
 		for _, arg := range args {
-			switch v := arg.(type) {
-			case float64:
-				fmt.Printf("%f ", v)
-			case types.UnofloatType:
-				fmt.Printf("%f ", float64(v))
-			default:
-				fmt.Printf("%v ", arg)
-			}
+			fmt.Print(types.FormatValue(arg), " ")
 		}
 
 		fmt.Println()
@@ -57,7 +53,7 @@ func RegisterBuiltins(register func(name string, fn types.IBuiltinFunc)) {
 			return nil
 		}
 
-		switch args[0].(type) {
+		switch v := args[0].(type) {
 		case int64:
 			return "int"
 		case uint64:
@@ -70,10 +66,60 @@ func RegisterBuiltins(register func(name string, fn types.IBuiltinFunc)) {
 			return "string"
 		case bool:
 			return "bool"
+		case types.Callable:
+			return "func"
+		case *types.ArrayValue:
+			return v.ElemType.String() + "[]"
+		case *types.MapValue:
+			return v.TypeString()
+		case *types.StructValue:
+			return v.TypeName
+		case types.EnumValue:
+			return v.TypeName
+		case types.Namespace:
+			return "module"
 		case nil:
 			return "nil"
 		default:
 			return "unknown"
 		}
 	})
+
+	register(CALL, func(args []any, i types.IInterpreter) any {
+		if len(args) < 1 {
+			i.LogError("call expects at least 1 argument")
+			return nil
+		}
+
+		if _, ok := args[0].(types.Callable); !ok {
+			i.LogError("call expects a function, got %T", args[0])
+			return nil
+		}
+
+		result, err := i.CallFunction(args[0], args[1:])
+		if err != nil {
+			i.LogError("%s", err)
+			return nil
+		}
+		return result
+	})
+
+	register(LEN, func(args []any, i types.IInterpreter) any {
+		if len(args) != 1 {
+			i.LogError("len expects exactly 1 argument")
+			return nil
+		}
+
+		switch v := args[0].(type) {
+		case *types.ArrayValue:
+			return int64(len(v.Elements))
+		case *types.MapValue:
+			return int64(v.Len())
+		case string:
+			return int64(utf8.RuneCountInString(v))
+		default:
+			i.LogError("len expects an array, a map or a string, got %T", args[0])
+			return nil
+		}
+	})
 }
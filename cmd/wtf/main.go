@@ -13,14 +13,14 @@ func main() {
 
 	if flag.NArg() < 1 {
 		interpreter.LogError("Usage: wtf [--config <config.json>] <file.wtf>")
-		return
+		os.Exit(1)
 	}
 
 	filename := flag.Arg(0)
 	content, err := os.ReadFile(filename)
 	if err != nil {
 		interpreter.LogError("Error reading file: %v", err)
-		return
+		os.Exit(1)
 	}
 
 	// Load config if provided
@@ -29,10 +29,12 @@ func main() {
 		cfg, err = config.LoadConfigFromFile(*configFile)
 		if err != nil {
 			interpreter.LogError("Error loading config: %v", err)
-			return
+			os.Exit(1)
 		}
 	}
 
 	i := interpreter.NewInterpreter(cfg)
-	i.Execute(string(content))
+	if err := i.ExecuteFile(filename, string(content)); err != nil {
+		os.Exit(1)
+	}
 }
@@ -23,9 +23,20 @@ type StringDefaults struct {
 	Length  MinMax[uint64] `json:"length"`
 }
 
+// Volatile assignment modes, see VolatileDefaults
+const (
+	VolatileAssignError = "error" // assigning to a volatile variable is a runtime error
+	VolatileAssignPin   = "pin"   // the assigned value sticks and the variable stops being volatile
+)
+
+type VolatileDefaults struct {
+	Assignment string `json:"assignment"`
+}
+
 type Config struct {
 	TypeDefaultRanges
 	StringDefaults
+	Volatile VolatileDefaults `json:"volatile"`
 }
 
 var DefaultConfig = Config{
@@ -54,6 +65,9 @@ var DefaultConfig = Config{
 			Max: 10,
 		},
 	},
+	Volatile: VolatileDefaults{
+		Assignment: VolatileAssignError,
+	},
 }
 
 // LoadConfigFromFile loads configuration from a JSON file
@@ -111,5 +125,9 @@ func validateConfig(cfg *Config) error {
 		return fmt.Errorf("string.length.min (%v) must be less than string.length.max (%v)", cfg.StringDefaults.Length.Min, cfg.StringDefaults.Length.Max)
 	}
 
+	if cfg.Volatile.Assignment != VolatileAssignError && cfg.Volatile.Assignment != VolatileAssignPin {
+		return fmt.Errorf("volatile.assignment (%q) must be %q or %q", cfg.Volatile.Assignment, VolatileAssignError, VolatileAssignPin)
+	}
+
 	return nil
 }
@@ -0,0 +1,76 @@
+package interpreter
+
+// evalAssertStmt fails with an AssertionError when the condition is false.
+// For a binary condition such as x > 0 both operands are evaluated once and
+// reported, so the error shows which values broke the assertion.
+// && and || keep their short-circuit evaluation and report no operands.
+func (i *Interpreter) evalAssertStmt(node *AssertStmt) (any, error) {
+	pos := node.Token.Position()
+
+	var result any
+	var operands []AssertionOperand
+	if bin, ok := node.Condition.(*BinaryExpr); ok && bin.Operator != AND && bin.Operator != OR && bin.Operator != IN {
+		left, err := i.Evaluate(bin.Left)
+		if err != nil {
+			return nil, err
+		}
+		right, err := i.Evaluate(bin.Right)
+		if err != nil {
+			return nil, err
+		}
+		result, err = i.applyOp(bin.Operator, left, right, bin.Token.Position())
+		if err != nil {
+			return nil, err
+		}
+		operands = appendOperand(operands, bin.Left, left)
+		operands = appendOperand(operands, bin.Right, right)
+	} else {
+		val, err := i.Evaluate(node.Condition)
+		if err != nil {
+			return nil, err
+		}
+		result = val
+		// A logical condition evaluates to false whenever it fails, so only a plain value such as ok or valid(x) is worth reporting
+		switch node.Condition.(type) {
+		case *BinaryExpr, *UnaryExpr:
+		default:
+			operands = appendOperand(operands, node.Condition, val)
+		}
+	}
+
+	passed, ok := result.(bool)
+	if !ok {
+		return nil, NewTypeMismatchErrorf(pos, "assert condition must evaluate to bool, got %s", getTypeString(result))
+	}
+	if passed {
+		return nil, nil
+	}
+
+	// The message is only evaluated when the assertion fails
+	var msg string
+	if node.Message != nil {
+		val, err := i.Evaluate(node.Message)
+		if err != nil {
+			return nil, err
+		}
+		if msg, ok = val.(string); !ok {
+			return nil, NewTypeMismatchErrorf(pos, "assert message must be a string, got %s", getTypeString(val))
+		}
+	}
+
+	return nil, &AssertionError{
+		Position: pos,
+		Expr:     node.Condition.String(),
+		Operands: operands,
+		Msg:      msg,
+	}
+}
+
+// appendOperand records an evaluated operand, literals are left out since
+// their value is already part of the expression text
+func appendOperand(operands []AssertionOperand, expr Expression, value any) []AssertionOperand {
+	if isLiteral(expr) {
+		return operands
+	}
+	return append(operands, AssertionOperand{Expr: expr.String(), Value: value})
+}
@@ -3,6 +3,7 @@ package interpreter
 import (
 	"bytes"
 	"strings"
+	"wtf-script/types"
 )
 
 // Node interface for all AST nodes
@@ -55,12 +56,20 @@ func (i *Identifier) String() string       { return i.Value }
 
 // VarDecl represents a variable declaration statement
 type VarDecl struct {
-	Token    Token // the token.TYPE_* token (int, float, etc)
-	Type     TokenType
-	Name     *Identifier
-	Value    Expression
-	RangeMin Expression // Optional: e.g. int(0, 100)
-	RangeMax Expression // Optional
+	Token        Token // the token.TYPE_* token (int, float, etc)
+	Type         TokenType
+	Name         *Identifier
+	Value        Expression
+	RangeMin     Expression    // Optional: e.g. int(0, 100), or the probability of bool(0.3)
+	RangeMax     Expression    // Optional, nil for bool(0.3)
+	Distribution *Distribution // Optional: e.g. float ~ normal(100, 15)
+	Charset      Expression    // Optional third argument of a string range: string(3, 8, "abc")
+	TypeName     string        // Set when declared with a named type such as Dice, Type is then its base type
+	Array        bool          // Optional: e.g. int[5] or int[], Type is then the element type
+	Size         Expression    // Optional array length
+	KeyType      TokenType     // Set for maps: map[string]int, Type is then the value type and the range is the size
+	Const        bool          // Declared with const: the value is fixed once and cannot be reassigned
+	Volatile     bool          // Declared with volatile: a fresh value is drawn on every read
 }
 
 func (vd *VarDecl) statementNode()       {}
@@ -68,17 +77,13 @@ func (vd *VarDecl) TokenLiteral() string { return vd.Token.Literal }
 func (vd *VarDecl) String() string {
 	var out bytes.Buffer
 
-	out.WriteString(vd.Token.Literal)
-
-	// Add range info if present
-	if vd.RangeMin != nil && vd.RangeMax != nil {
-		out.WriteString("(")
-		out.WriteString(vd.RangeMin.String())
-		out.WriteString(", ")
-		out.WriteString(vd.RangeMax.String())
-		out.WriteString(")")
+	if vd.Const {
+		out.WriteString("const ")
 	}
-
+	if vd.Volatile {
+		out.WriteString("volatile ")
+	}
+	out.WriteString(vd.typeString())
 	out.WriteString(" ")
 	out.WriteString(vd.Name.String())
 
@@ -91,6 +96,71 @@ func (vd *VarDecl) String() string {
 	return out.String()
 }
 
+// typeString renders the declared type, e.g. int(1, 7)[10], float ~ normal(0, 1) or Dice
+func (vd *VarDecl) typeString() string {
+	var out bytes.Buffer
+
+	if vd.TypeName != "" {
+		out.WriteString(vd.TypeName)
+	} else {
+		out.WriteString(vd.Token.Literal)
+		out.WriteString(vd.refinementString())
+	}
+
+	if vd.Array {
+		out.WriteString("[")
+		if vd.Size != nil {
+			out.WriteString(vd.Size.String())
+		}
+		out.WriteString("]")
+	}
+
+	if vd.KeyType != "" {
+		out.WriteString(mapTypeSuffix(vd.KeyType, vd.Type))
+	}
+	return out.String()
+}
+
+// refinementString renders the range and distribution of a type, e.g. (0, 10) ~ normal(5, 2)
+func (vd *VarDecl) refinementString() string {
+	var out bytes.Buffer
+
+	if vd.RangeMin != nil {
+		out.WriteString("(")
+		out.WriteString(vd.RangeMin.String())
+		if vd.RangeMax != nil {
+			out.WriteString(", ")
+			out.WriteString(vd.RangeMax.String())
+		}
+		if vd.Charset != nil {
+			out.WriteString(", ")
+			out.WriteString(vd.Charset.String())
+		}
+		out.WriteString(")")
+	}
+
+	if vd.Distribution != nil {
+		out.WriteString(" ~ ")
+		out.WriteString(vd.Distribution.String())
+	}
+	return out.String()
+}
+
+// Distribution is the ~ name(args) part of a declaration such as float ~ normal(100, 15) iq
+type Distribution struct {
+	Token Token // the '~' token
+	Name  *Identifier
+	Args  []Expression
+}
+
+func (d *Distribution) String() string {
+	args := make([]string, 0, len(d.Args))
+	for _, arg := range d.Args {
+		args = append(args, arg.String())
+	}
+	return d.Name.String() + "(" + strings.Join(args, ", ") + ")"
+}
+
 // AssignStmt represents an assignment statement
 type AssignStmt struct {
 	Token Token // the token.ASSIGN token
@@ -142,7 +212,11 @@ func (bin *BinaryExpr) String() string {
 
 	out.WriteString("(")
 	out.WriteString(bin.Left.String())
-	out.WriteString(" " + string(bin.Operator) + " ")
+	operator := string(bin.Operator)
+	if bin.Operator == IN {
+		operator = bin.Token.Literal // keyword operators render as written: key in m
+	}
+	out.WriteString(" " + operator + " ")
 	out.WriteString(bin.Right.String())
 	out.WriteString(")")
 
@@ -214,6 +288,26 @@ func (fl *FloatLiteral) expressionNode()      {}
 func (fl *FloatLiteral) TokenLiteral() string { return fl.Token.Literal }
 func (fl *FloatLiteral) String() string       { return fl.Token.Literal }
 
+// UintLiteral represents an integer with the u suffix, such as 5u
+type UintLiteral struct {
+	Token Token
+	Value uint64
+}
+
+func (ul *UintLiteral) expressionNode()      {}
+func (ul *UintLiteral) TokenLiteral() string { return ul.Token.Literal }
+func (ul *UintLiteral) String() string       { return ul.Token.Literal }
+
+// PercentLiteral represents a unofloat written as a percentage, 30% has the Value 0.3
+type PercentLiteral struct {
+	Token Token
+	Value float64
+}
+
+func (pl *PercentLiteral) expressionNode()      {}
+func (pl *PercentLiteral) TokenLiteral() string { return pl.Token.Literal }
+func (pl *PercentLiteral) String() string       { return pl.Token.Literal }
+
 // StringLiteral represents a string
 type StringLiteral struct {
 	Token Token
@@ -278,3 +372,594 @@ func (is *IfStmt) String() string {
 
 	return out.String()
 }
+
+// WhileStmt represents a while or whilerand loop
+type WhileStmt struct {
+	Token     Token      // the 'while' or 'whilerand' token
+	Condition Expression // the probability for whilerand, nil for the default
+	Body      *BlockStmt
+}
+
+func (ws *WhileStmt) statementNode()       {}
+func (ws *WhileStmt) TokenLiteral() string { return ws.Token.Literal }
+func (ws *WhileStmt) String() string {
+	var out bytes.Buffer
+
+	out.WriteString(ws.Token.Literal)
+	if ws.Condition != nil {
+		out.WriteString(" ")
+		out.WriteString(ws.Condition.String())
+	}
+	out.WriteString(" ")
+	out.WriteString(ws.Body.String())
+
+	return out.String()
+}
+
+// BreakStmt represents a break statement
+type BreakStmt struct {
+	Token Token // the 'break' token
+}
+
+func (bs *BreakStmt) statementNode()       {}
+func (bs *BreakStmt) TokenLiteral() string { return bs.Token.Literal }
+func (bs *BreakStmt) String() string       { return "break;" }
+
+// ContinueStmt represents a continue statement
+type ContinueStmt struct {
+	Token Token // the 'continue' token
+}
+
+func (cs *ContinueStmt) statementNode()       {}
+func (cs *ContinueStmt) TokenLiteral() string { return cs.Token.Literal }
+func (cs *ContinueStmt) String() string       { return "continue;" }
+
+// ForStmt represents a C-style for loop: for (init; condition; post) { ... }
+type ForStmt struct {
+	Token     Token     // the 'for' token
+	Init      Statement // optional, usually a VarDecl or AssignStmt
+	Condition Expression
+	Post      Statement // optional, usually an AssignStmt
+	Body      *BlockStmt
+}
+
+func (fs *ForStmt) statementNode()       {}
+func (fs *ForStmt) TokenLiteral() string { return fs.Token.Literal }
+func (fs *ForStmt) String() string {
+	var out bytes.Buffer
+
+	out.WriteString("for (")
+	if fs.Init != nil {
+		out.WriteString(strings.TrimSuffix(fs.Init.String(), ";"))
+	}
+	out.WriteString("; ")
+	if fs.Condition != nil {
+		out.WriteString(fs.Condition.String())
+	}
+	out.WriteString("; ")
+	if fs.Post != nil {
+		out.WriteString(strings.TrimSuffix(fs.Post.String(), ";"))
+	}
+	out.WriteString(") ")
+	out.WriteString(fs.Body.String())
+
+	return out.String()
+}
+
+// ForInStmt represents a range loop: for i in 0..10 { ... }
+type ForInStmt struct {
+	Token    Token // the 'for' token
+	Variable *Identifier
+	Iterable Expression
+	Body     *BlockStmt
+}
+
+func (fs *ForInStmt) statementNode()       {}
+func (fs *ForInStmt) TokenLiteral() string { return fs.Token.Literal }
+func (fs *ForInStmt) String() string {
+	var out bytes.Buffer
+
+	out.WriteString("for ")
+	out.WriteString(fs.Variable.String())
+	out.WriteString(" in ")
+	out.WriteString(fs.Iterable.String())
+	out.WriteString(" ")
+	out.WriteString(fs.Body.String())
+
+	return out.String()
+}
+
+// RangeExpr represents an inclusive range, either 1..6 or a typed random range such as int(1, 6)
+type RangeExpr struct {
+	Token Token     // the '..' token or the type token
+	Type  TokenType // TYPE_* for typed ranges, empty for a..b
+	Start Expression
+	End   Expression
+}
+
+func (re *RangeExpr) expressionNode()      {}
+func (re *RangeExpr) TokenLiteral() string { return re.Token.Literal }
+func (re *RangeExpr) String() string {
+	if re.Type != "" {
+		return re.Token.Literal + "(" + re.Start.String() + ", " + re.End.String() + ")"
+	}
+	return "(" + re.Start.String() + ".." + re.End.String() + ")"
+}
+
+// TemplateString represents a string literal with ${...} interpolations
+type TemplateString struct {
+	Token Token        // the TEMPLATE token, its literal is the source text
+	Parts []Expression // *StringLiteral for the text between the embedded expressions
+}
+
+func (ts *TemplateString) expressionNode()      {}
+func (ts *TemplateString) TokenLiteral() string { return ts.Token.Literal }
+func (ts *TemplateString) String() string       { return ts.Token.Literal }
+
+// ConditionalExpr represents cond ? a : b, or the random pick ifrand(p) ? a : b
+type ConditionalExpr struct {
+	Token       Token      // the '?' or 'ifrand' token
+	Condition   Expression // the probability for ifrand, nil for the default
+	Consequence Expression
+	Alternative Expression
+}
+
+func (ce *ConditionalExpr) expressionNode()      {}
+func (ce *ConditionalExpr) TokenLiteral() string { return ce.Token.Literal }
+func (ce *ConditionalExpr) String() string {
+	var out bytes.Buffer
+
+	out.WriteString("(")
+	if ce.Token.Type == IFRAND {
+		out.WriteString("ifrand")
+		if ce.Condition != nil {
+			out.WriteString("(" + ce.Condition.String() + ")")
+		}
+	} else {
+		out.WriteString(ce.Condition.String())
+	}
+	out.WriteString(" ? ")
+	out.WriteString(ce.Consequence.String())
+	out.WriteString(" : ")
+	out.WriteString(ce.Alternative.String())
+	out.WriteString(")")
+
+	return out.String()
+}
+
+// RepeatStmt represents a fixed or random count loop: repeat 3 { ... } or repeatrand(1, 6) { ... }
+type RepeatStmt struct {
+	Token Token      // the 'repeat' or 'repeatrand' token
+	Count Expression // used by repeat
+	Min   Expression // used by repeatrand
+	Max   Expression // used by repeatrand
+	Body  *BlockStmt
+}
+
+func (rs *RepeatStmt) statementNode()       {}
+func (rs *RepeatStmt) TokenLiteral() string { return rs.Token.Literal }
+func (rs *RepeatStmt) String() string {
+	var out bytes.Buffer
+
+	out.WriteString(rs.Token.Literal)
+	if rs.Count != nil {
+		out.WriteString(" ")
+		out.WriteString(rs.Count.String())
+	} else {
+		out.WriteString("(")
+		out.WriteString(rs.Min.String())
+		out.WriteString(", ")
+		out.WriteString(rs.Max.String())
+		out.WriteString(")")
+	}
+	out.WriteString(" ")
+	out.WriteString(rs.Body.String())
+
+	return out.String()
+}
+
+// FunctionLiteral represents the signature and body of a function
+type FunctionLiteral struct {
+	Token      Token      // the 'func' token
+	Parameters []*VarDecl // typed parameters, optionally ranged: int(1, 6) face
+	ReturnType TokenType  // TYPE_* token, empty if the function returns nothing
+	ReturnsArr bool       // the function returns an array of ReturnType
+	ReturnKey  TokenType  // the function returns a map from ReturnKey to ReturnType
+	ReturnName string     // the name of a struct or enum return type, ReturnType is then IDENT or ENUM
+	Body       *BlockStmt
+}
+
+func (fl *FunctionLiteral) expressionNode()      {}
+func (fl *FunctionLiteral) TokenLiteral() string { return fl.Token.Literal }
+func (fl *FunctionLiteral) String() string       { return fl.Token.Literal + fl.signatureAndBody() }
+
+// signatureAndBody renders everything after the function name: (params) type { body }
+func (fl *FunctionLiteral) signatureAndBody() string {
+	var out bytes.Buffer
+
+	params := []string{}
+	for _, p := range fl.Parameters {
+		params = append(params, strings.TrimSuffix(p.String(), ";"))
+	}
+
+	out.WriteString("(")
+	out.WriteString(strings.Join(params, ", "))
+	out.WriteString(")")
+	if fl.ReturnType != "" {
+		out.WriteString(" ")
+		out.WriteString(fl.returnTypeString())
+	}
+	out.WriteString(" { ")
+	out.WriteString(fl.Body.String())
+	out.WriteString(" }")
+
+	return out.String()
+}
+
+// returnTypeString renders the declared return type, e.g. int, int[] or map[string]int
+func (fl *FunctionLiteral) returnTypeString() string {
+	switch {
+	case fl.ReturnName != "" && fl.ReturnsArr:
+		return fl.ReturnName + "[]"
+	case fl.ReturnName != "":
+		return fl.ReturnName
+	case fl.ReturnKey != "":
+		return "map" + mapTypeSuffix(fl.ReturnKey, fl.ReturnType)
+	case fl.ReturnsArr:
+		return types.VarType(varTypeFromToken(fl.ReturnType)).String() + "[]"
+	default:
+		return types.VarType(varTypeFromToken(fl.ReturnType)).String()
+	}
+}
+
+// mapTypeSuffix renders the [key]value part of a map type
+func mapTypeSuffix(key, value TokenType) string {
+	return "[" + types.VarType(varTypeFromToken(key)).String() + "]" + types.VarType(varTypeFromToken(value)).String()
+}
+
+// FuncDecl represents a named function declaration: func name(params) type { ... }
+type FuncDecl struct {
+	Token    Token // the 'func' token
+	Name     *Identifier
+	Function *FunctionLiteral
+}
+
+func (fd *FuncDecl) statementNode()       {}
+func (fd *FuncDecl) TokenLiteral() string { return fd.Token.Literal }
+func (fd *FuncDecl) String() string {
+	return fd.Token.Literal + " " + fd.Name.String() + fd.Function.signatureAndBody()
+}
+
+// ReturnStmt represents a return statement
+type ReturnStmt struct {
+	Token Token      // the 'return' token
+	Value Expression // optional
+}
+
+func (rs *ReturnStmt) statementNode()       {}
+func (rs *ReturnStmt) TokenLiteral() string { return rs.Token.Literal }
+func (rs *ReturnStmt) String() string {
+	if rs.Value != nil {
+		return rs.Token.Literal + " " + rs.Value.String() + ";"
+	}
+	return rs.Token.Literal + ";"
+}
+
+// ArrayLiteral represents an array literal: [1, 2, 3]
+type ArrayLiteral struct {
+	Token    Token // the '[' token
+	Elements []Expression
+}
+
+func (al *ArrayLiteral) expressionNode()      {}
+func (al *ArrayLiteral) TokenLiteral() string { return al.Token.Literal }
+func (al *ArrayLiteral) String() string {
+	elements := []string{}
+	for _, el := range al.Elements {
+		elements = append(elements, el.String())
+	}
+	return "[" + strings.Join(elements, ", ") + "]"
+}
+
+// IndexExpr represents an index expression: xs[i]
+type IndexExpr struct {
+	Token Token // the '[' token
+	Left  Expression
+	Index Expression
+}
+
+func (ie *IndexExpr) expressionNode()      {}
+func (ie *IndexExpr) TokenLiteral() string { return ie.Token.Literal }
+func (ie *IndexExpr) String() string {
+	return "(" + ie.Left.String() + "[" + ie.Index.String() + "])"
+}
+
+// IndexAssignStmt represents an assignment to an element: xs[i] = value
+type IndexAssignStmt struct {
+	Token  Token // the '=' token
+	Target *IndexExpr
+	Value  Expression
+}
+
+func (ia *IndexAssignStmt) statementNode()       {}
+func (ia *IndexAssignStmt) TokenLiteral() string { return ia.Token.Literal }
+func (ia *IndexAssignStmt) String() string {
+	return ia.Target.Left.String() + "[" + ia.Target.Index.String() + "] = " + ia.Value.String() + ";"
+}
+
+// MapLiteral represents a map literal: {"a": 1, "b": 2}
+type MapLiteral struct {
+	Token  Token // the '{' token
+	Keys   []Expression
+	Values []Expression // Values[i] belongs to Keys[i], in source order
+}
+
+func (ml *MapLiteral) expressionNode()      {}
+func (ml *MapLiteral) TokenLiteral() string { return ml.Token.Literal }
+func (ml *MapLiteral) String() string {
+	pairs := []string{}
+	for idx, key := range ml.Keys {
+		pairs = append(pairs, key.String()+": "+ml.Values[idx].String())
+	}
+	return "{" + strings.Join(pairs, ", ") + "}"
+}
+
+// StructDecl represents a struct type declaration: struct User { string name; int(18, 99) age; }
+type StructDecl struct {
+	Token  Token // the 'struct' token
+	Name   *Identifier
+	Fields []*VarDecl
+}
+
+func (sd *StructDecl) statementNode()       {}
+func (sd *StructDecl) TokenLiteral() string { return sd.Token.Literal }
+func (sd *StructDecl) String() string {
+	var out bytes.Buffer
+
+	out.WriteString("struct ")
+	out.WriteString(sd.Name.String())
+	out.WriteString(" { ")
+	for _, f := range sd.Fields {
+		out.WriteString(f.String())
+		out.WriteString(" ")
+	}
+	out.WriteString("}")
+
+	return out.String()
+}
+
+// TypeDecl represents a named type: type Dice = int(1, 7);
+type TypeDecl struct {
+	Token Token // the 'type' token
+	Name  *Identifier
+	Base  *VarDecl // the base type with its range and distribution, without a name
+}
+
+func (td *TypeDecl) statementNode()       {}
+func (td *TypeDecl) TokenLiteral() string { return td.Token.Literal }
+func (td *TypeDecl) String() string {
+	return "type " + td.Name.String() + " = " + td.Base.typeString() + ";"
+}
+
+// NamedBound stands for a bound of a named type where the type is used, such as the 1 of
+// type Dice = int(1, 7); in Dice d;. It evaluates to the value the bound had when the
+// type was declared, so every use of Dice draws from the same range.
+type NamedBound struct {
+	Token Token // the name of the type at the use site
+	Type  *TypeDecl
+	Expr  Expression // the bound as written in the type declaration
+}
+
+func (nb *NamedBound) expressionNode()      {}
+func (nb *NamedBound) TokenLiteral() string { return nb.Token.Literal }
+func (nb *NamedBound) String() string       { return nb.Expr.String() }
+
+// EnumDecl represents an enum declaration: enum Color { Red, Green, Blue }
+// or, with weights, enum Status { Ok: 90, Error: 10 }
+type EnumDecl struct {
+	Token   Token // the 'enum' token
+	Name    *Identifier
+	Members []*EnumMember
+}
+
+func (ed *EnumDecl) statementNode()       {}
+func (ed *EnumDecl) TokenLiteral() string { return ed.Token.Literal }
+func (ed *EnumDecl) String() string {
+	members := make([]string, len(ed.Members))
+	for i, m := range ed.Members {
+		members[i] = m.String()
+	}
+	return "enum " + ed.Name.String() + " { " + strings.Join(members, ", ") + " }"
+}
+
+// EnumMember is a single member of an enum with its optional weight
+type EnumMember struct {
+	Name   *Identifier
+	Weight Expression // nil when every member of the enum is equally likely
+}
+
+func (em *EnumMember) String() string {
+	if em.Weight == nil {
+		return em.Name.String()
+	}
+	return em.Name.String() + ": " + em.Weight.String()
+}
+
+// MemberExpr represents a field access: u.age
+type MemberExpr struct {
+	Token  Token // the '.' token
+	Object Expression
+	Member *Identifier
+}
+
+func (me *MemberExpr) expressionNode()      {}
+func (me *MemberExpr) TokenLiteral() string { return me.Token.Literal }
+func (me *MemberExpr) String() string {
+	return me.Object.String() + "." + me.Member.String()
+}
+
+// MemberAssignStmt represents an assignment to a field: u.age = 30
+type MemberAssignStmt struct {
+	Token  Token // the '=' token
+	Target *MemberExpr
+	Value  Expression
+}
+
+func (ma *MemberAssignStmt) statementNode()       {}
+func (ma *MemberAssignStmt) TokenLiteral() string { return ma.Token.Literal }
+func (ma *MemberAssignStmt) String() string {
+	return ma.Target.String() + " = " + ma.Value.String() + ";"
+}
+
+// ImportStmt represents a module import: import "dice.wtf" as dice;
+type ImportStmt struct {
+	Token Token // the 'import' token
+	Path  *StringLiteral
+	Alias *Identifier // Optional: defaults to the file name without its extension
+}
+
+func (is *ImportStmt) statementNode()       {}
+func (is *ImportStmt) TokenLiteral() string { return is.Token.Literal }
+func (is *ImportStmt) String() string {
+	var out bytes.Buffer
+
+	out.WriteString("import ")
+	out.WriteString(is.Path.String())
+	if is.Alias != nil {
+		out.WriteString(" as ")
+		out.WriteString(is.Alias.String())
+	}
+	out.WriteString(";")
+
+	return out.String()
+}
+
+// MatchStmt represents a match statement: match score { 90..100 => { ... }, _ => { ... } }
+type MatchStmt struct {
+	Token   Token // the 'match' token
+	Subject Expression
+	Arms    []*MatchArm
+}
+
+func (ms *MatchStmt) statementNode()       {}
+func (ms *MatchStmt) TokenLiteral() string { return ms.Token.Literal }
+func (ms *MatchStmt) String() string {
+	arms := make([]string, len(ms.Arms))
+	for i, arm := range ms.Arms {
+		arms[i] = arm.String()
+	}
+	return "match " + ms.Subject.String() + " { " + strings.Join(arms, ", ") + " }"
+}
+
+// MatchArm is a single pattern => { ... } arm of a match statement
+type MatchArm struct {
+	Token   Token      // the first token of the pattern
+	Pattern Expression // a literal or a range, nil for the default arm _
+	Body    *BlockStmt
+}
+
+func (ma *MatchArm) String() string {
+	pattern := MatchWildcard
+	if ma.Pattern != nil {
+		pattern = ma.Pattern.String()
+	}
+	return pattern + " => { " + ma.Body.String() + " }"
+}
+
+// ChooseStmt represents a weighted random choice: choose { 3 => { ... }, 1 => { ... } }
+type ChooseStmt struct {
+	Token Token // the 'choose' token
+	Arms  []*ChooseArm
+}
+
+func (cs *ChooseStmt) statementNode()       {}
+func (cs *ChooseStmt) TokenLiteral() string { return cs.Token.Literal }
+func (cs *ChooseStmt) String() string {
+	arms := make([]string, len(cs.Arms))
+	for i, arm := range cs.Arms {
+		arms[i] = arm.String()
+	}
+	return "choose { " + strings.Join(arms, ", ") + " }"
+}
+
+// ChooseArm is a single weight => { ... } arm of a choose statement
+type ChooseArm struct {
+	Token  Token // the first token of the weight
+	Weight Expression
+	Body   *BlockStmt
+}
+
+func (ca *ChooseArm) String() string {
+	return ca.Weight.String() + " => { " + ca.Body.String() + " }"
+}
+
+// TryStmt represents try { ... } catch (err) { ... }
+type TryStmt struct {
+	Token   Token // the 'try' token
+	Body    *BlockStmt
+	Param   *Identifier // the error binding, nil for catch { ... }
+	Handler *BlockStmt
+}
+
+func (ts *TryStmt) statementNode()       {}
+func (ts *TryStmt) TokenLiteral() string { return ts.Token.Literal }
+func (ts *TryStmt) String() string {
+	var out bytes.Buffer
+
+	out.WriteString("try ")
+	out.WriteString(ts.Body.String())
+	out.WriteString(" catch ")
+	if ts.Param != nil {
+		out.WriteString("(" + ts.Param.String() + ") ")
+	}
+	out.WriteString(ts.Handler.String())
+
+	return out.String()
+}
+
+// ThrowStmt represents throw "message";
+type ThrowStmt struct {
+	Token Token // the 'throw' token
+	Value Expression
+}
+
+func (ts *ThrowStmt) statementNode()       {}
+func (ts *ThrowStmt) TokenLiteral() string { return ts.Token.Literal }
+func (ts *ThrowStmt) String() string {
+	return ts.Token.Literal + " " + ts.Value.String() + ";"
+}
+
+// RerollStmt draws fresh values from the declarations of variables: reroll d; or reroll all;
+type RerollStmt struct {
+	Token Token         // the 'reroll' token
+	Names []*Identifier // the variables to reroll, nil for reroll all;
+}
+
+func (rs *RerollStmt) statementNode()       {}
+func (rs *RerollStmt) TokenLiteral() string { return rs.Token.Literal }
+func (rs *RerollStmt) String() string {
+	if rs.Names == nil {
+		return "reroll " + RerollAll + ";"
+	}
+	names := make([]string, len(rs.Names))
+	for i, name := range rs.Names {
+		names[i] = name.String()
+	}
+	return "reroll " + strings.Join(names, ", ") + ";"
+}
+
+// AssertStmt represents assert(condition, "message");, the message is optional
+type AssertStmt struct {
+	Token     Token // the 'assert' token
+	Condition Expression
+	Message   Expression
+}
+
+func (as *AssertStmt) statementNode()       {}
+func (as *AssertStmt) TokenLiteral() string { return as.Token.Literal }
+func (as *AssertStmt) String() string {
+	if as.Message != nil {
+		return "assert(" + as.Condition.String() + ", " + as.Message.String() + ");"
+	}
+	return "assert(" + as.Condition.String() + ");"
+}
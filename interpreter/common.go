@@ -1,7 +1,8 @@
 package interpreter
 
-// Position represents a position (line and column) in the source code
+// Position represents a position (file, line and column) in the source code
 type Position struct {
+	File   string // empty for code that does not come from a file
 	Line   int
 	Column int
 }
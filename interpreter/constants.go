@@ -19,3 +19,51 @@ const (
 const (
 	DefaultIfrandProbability = 0.5
 )
+
+// ErrorTypeName is the struct type name of the error bound by catch (err)
+const ErrorTypeName = "Error"
+
+// MatchWildcard is the pattern of the default arm of a match statement
+const MatchWildcard = "_"
+
+// RerollAll is the target of reroll all;, which rerolls every variable in scope
+const RerollAll = "all"
+
+// Loop limits
+const (
+	// MaxShuffledRangeSize caps how many values a typed range such as int(a, b) may visit in a for loop
+	MaxShuffledRangeSize = 1_000_000
+)
+
+// Distribution limits
+const (
+	// MaxDistributionDraws is how many samples a declaration such as int(0, 10) ~ poisson(3) n
+	// may reject for falling outside its range before giving up
+	MaxDistributionDraws = 1000
+
+	// MaxPoissonMean and MaxBinomialTrials bound the work of a single poisson or binomial draw
+	MaxPoissonMean    = 1_000_000
+	MaxBinomialTrials = 1_000_000
+)
+
+// Function call limits
+const (
+	// MaxCallDepth bounds recursion so runaway scripts fail with a runtime error instead of crashing
+	MaxCallDepth = 1000
+)
+
+// Collection limits
+const (
+	// MaxArraySize caps how many elements a randomly filled array such as int[n] xs may have
+	MaxArraySize = 1_000_000
+
+	// MaxMapSize caps how many entries a randomly populated map such as map(a, b)[string]int m may have
+	MaxMapSize = 1_000_000
+
+	// MaxRandomStringLength caps the length of a random string such as string(a, b) s
+	MaxRandomStringLength = 1_000_000
+
+	// MaxRandomKeyAttempts is how many duplicate keys in a row a random map population tolerates
+	// before giving up, e.g. when asking for three distinct bool keys
+	MaxRandomKeyAttempts = 100
+)
@@ -0,0 +1,217 @@
+package interpreter
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+
+	"wtf-script/types"
+)
+
+// distribution describes a probability distribution usable in declarations
+// such as float ~ normal(100, 15) iq
+type distribution struct {
+	params []string
+	// check returns why the parameters cannot be sampled, or "" if they can
+	check  func(args []float64) string
+	sample func(r *rand.Rand, args []float64) float64
+}
+
+var distributions = map[string]distribution{
+	"normal": {
+		params: []string{"mean", "stddev"},
+		check: func(args []float64) string {
+			if args[1] < 0 {
+				return "stddev must not be negative"
+			}
+			return ""
+		},
+		sample: func(r *rand.Rand, args []float64) float64 {
+			return args[0] + r.NormFloat64()*args[1]
+		},
+	},
+	"exponential": {
+		params: []string{"rate"},
+		check: func(args []float64) string {
+			if args[0] <= 0 {
+				return "rate must be positive"
+			}
+			return ""
+		},
+		sample: func(r *rand.Rand, args []float64) float64 {
+			return r.ExpFloat64() / args[0]
+		},
+	},
+	"poisson": {
+		params: []string{"mean"},
+		check: func(args []float64) string {
+			if args[0] <= 0 || args[0] > MaxPoissonMean {
+				return fmt.Sprintf("mean must be positive and at most %d", MaxPoissonMean)
+			}
+			return ""
+		},
+		sample: samplePoisson,
+	},
+	"binomial": {
+		params: []string{"trials", "p"},
+		check: func(args []float64) string {
+			if args[0] != math.Trunc(args[0]) || args[0] < 0 || args[0] > MaxBinomialTrials {
+				return fmt.Sprintf("trials must be a whole number between 0 and %d", MaxBinomialTrials)
+			}
+			if args[1] < 0 || args[1] > 1 {
+				return "p must be between 0 and 1"
+			}
+			return ""
+		},
+		sample: func(r *rand.Rand, args []float64) float64 {
+			hits := 0
+			for n := 0; n < int(args[0]); n++ {
+				if r.Float64() < args[1] {
+					hits++
+				}
+			}
+			return float64(hits)
+		},
+	},
+	"triangular": {
+		params: []string{"min", "mode", "max"},
+		check: func(args []float64) string {
+			if args[0] >= args[2] {
+				return "min must be less than max"
+			}
+			if args[1] < args[0] || args[1] > args[2] {
+				return "mode must lie between min and max"
+			}
+			return ""
+		},
+		sample: sampleTriangular,
+	},
+}
+
+// samplePoisson uses Knuth's multiplication method. Large means are split into
+// chunks whose sum is Poisson distributed as well, so exp(-mean) cannot underflow.
+func samplePoisson(r *rand.Rand, args []float64) float64 {
+	const chunk = 500.0
+	count := 0
+	for mean := args[0]; mean > 0; mean -= chunk {
+		limit := math.Exp(-min(mean, chunk))
+		for p := r.Float64(); p > limit; p *= r.Float64() {
+			count++
+		}
+	}
+	return float64(count)
+}
+
+// sampleTriangular inverts the cumulative distribution of triangular(min, mode, max)
+func sampleTriangular(r *rand.Rand, args []float64) float64 {
+	lo, mode, hi := args[0], args[1], args[2]
+	u := r.Float64()
+	if u < (mode-lo)/(hi-lo) {
+		return lo + math.Sqrt(u*(hi-lo)*(mode-lo))
+	}
+	return hi - math.Sqrt((1-u)*(hi-lo)*(hi-mode))
+}
+
+// distributionSampler evaluates the distribution parameters and the optional
+// range of a declaration such as int(0, 10) ~ poisson(3) n once, and returns a
+// function that draws one value per call. Samples outside the range, or
+// outside the declared type as with a negative uint, are drawn again.
+func (i *Interpreter) distributionSampler(decl *VarDecl) (func() (any, error), error) {
+	dist := decl.Distribution
+	pos := dist.Token.Position()
+	spec := distributions[dist.Name.Value]
+
+	args := make([]float64, len(dist.Args))
+	for idx, argExpr := range dist.Args {
+		val, err := i.Evaluate(argExpr)
+		if err != nil {
+			return nil, err
+		}
+		arg, ok := toFloat64(val)
+		if !ok || math.IsNaN(arg) || math.IsInf(arg, 0) {
+			return nil, NewInvalidDistributionError(pos, "%s %s must be a finite number, got %v",
+				dist.Name.Value, spec.params[idx], val)
+		}
+		args[idx] = arg
+	}
+	if reason := spec.check(args); reason != "" {
+		return nil, NewInvalidDistributionError(pos, "invalid %s: %s", dist.Name.Value, reason)
+	}
+
+	lo, hi := math.Inf(-1), math.Inf(1)
+	baseType := types.VarType(varTypeFromToken(decl.Type))
+	bounds := fmt.Sprintf("the %s type", baseType)
+	if decl.RangeMin != nil {
+		bounds = fmt.Sprintf("%s(%s, %s)", baseType, decl.RangeMin, decl.RangeMax)
+		minVal, maxVal, err := i.evalRangeBounds(decl)
+		if err != nil {
+			return nil, err
+		}
+		if lo, hi, err = distributionBounds(decl.Type, minVal, maxVal, decl.Token.Position()); err != nil {
+			return nil, err
+		}
+	}
+
+	return func() (any, error) {
+		for n := 0; n < MaxDistributionDraws; n++ {
+			sample := spec.sample(i.Rand, args)
+			if decl.Type == TYPE_INT || decl.Type == TYPE_UINT {
+				sample = math.Round(sample)
+			}
+			if sample < lo || sample >= hi {
+				continue
+			}
+			if val, ok := sampleToType(decl.Type, sample); ok {
+				return val, nil
+			}
+		}
+		return nil, NewInvalidRangeError(pos, fmt.Sprintf("no sample of %s fell within %s after %d draws",
+			dist, bounds, MaxDistributionDraws))
+	}, nil
+}
+
+// distributionBounds checks the range of a distribution declaration with the
+// rules of randomValueInRange and returns it as floats
+func distributionBounds(t TokenType, min, max any, pos *Position) (float64, float64, error) {
+	if max == nil {
+		return 0, 0, NewInvalidRangeError(pos, fmt.Sprintf("%s range needs a min and a max", types.VarType(varTypeFromToken(t))))
+	}
+	if t == TYPE_UINT && (isNegative(min) || isNegative(max)) {
+		return 0, 0, NewInvalidRangeError(pos, "uint range cannot have negative bounds")
+	}
+	lo, ok1 := toFloat64(min)
+	hi, ok2 := toFloat64(max)
+	if !ok1 || !ok2 {
+		return 0, 0, NewInvalidRangeError(pos, fmt.Sprintf("invalid types for %s range", types.VarType(varTypeFromToken(t))))
+	}
+	if err := checkRange(lo, hi, pos); err != nil {
+		return 0, 0, err
+	}
+	if t == TYPE_UNOFLOAT && (lo < UnofloatMin || hi > UnofloatMax) {
+		return 0, 0, NewInvalidRangeError(pos, "unofloat range must lie within 0 and 1")
+	}
+	return lo, hi, nil
+}
+
+// sampleToType converts a sample, already rounded for int and uint, to the
+// declared type. It reports false when the sample does not fit the type.
+func sampleToType(t TokenType, sample float64) (any, bool) {
+	switch t {
+	case TYPE_INT:
+		if sample < math.MinInt64 || sample >= math.MaxInt64 {
+			return nil, false
+		}
+		return int64(sample), true
+	case TYPE_UINT:
+		if sample < 0 || sample >= math.MaxUint64 {
+			return nil, false
+		}
+		return uint64(sample), true
+	case TYPE_UNOFLOAT:
+		if sample < UnofloatMin || sample > UnofloatMax {
+			return nil, false
+		}
+		return types.UnofloatType(sample), true
+	}
+	return sample, true
+}
@@ -0,0 +1,117 @@
+package interpreter
+
+import "wtf-script/types"
+
+// enumType is the runtime definition of an enum declaration.
+// The weights of its members are evaluated once, when the enum is declared.
+type enumType struct {
+	Decl    *EnumDecl
+	module  string    // path of the declaring module, empty for the main program
+	weights []float64 // nil when every member is equally likely
+}
+
+// member returns the member with the given name
+func (e *enumType) member(name string) (types.EnumValue, bool) {
+	for _, m := range e.Decl.Members {
+		if m.Name.Value == name {
+			return types.EnumValue{TypeName: e.Decl.Name.Value, Module: e.module, Member: name}, true
+		}
+	}
+	return types.EnumValue{}, false
+}
+
+func (i *Interpreter) evalEnumDecl(node *EnumDecl) (any, error) {
+	reg := i.env.typeRegistry()
+	if _, ok := reg.enums[node.Name.Value]; ok {
+		return nil, NewRuntimeError(node.Name.Token.Position(),
+			"enum already declared: %s", node.Name.Value)
+	}
+
+	def := &enumType{Decl: node, module: reg.module}
+	if node.Members[0].Weight != nil {
+		def.weights = make([]float64, len(node.Members))
+		for idx, m := range node.Members {
+			weight, err := i.evalWeight("enum", m.Weight, m.Name.Token.Position())
+			if err != nil {
+				return nil, err
+			}
+			def.weights[idx] = weight
+		}
+	}
+	reg.enums[node.Name.Value] = def
+	return nil, nil
+}
+
+// pickEnumMember draws a member of the named enum, uniformly or honoring its weights
+func (i *Interpreter) pickEnumMember(reg *typeRegistry, name string, pos *Position) (types.EnumValue, error) {
+	def, ok := reg.enums[name]
+	if !ok {
+		return types.EnumValue{}, NewRuntimeError(pos, "unknown type: %s", name)
+	}
+
+	idx := 0
+	if def.weights != nil {
+		idx = i.pickWeighted(def.weights)
+	} else {
+		idx = i.Rand.Intn(len(def.Decl.Members))
+	}
+	return types.EnumValue{TypeName: name, Module: reg.module, Member: def.Decl.Members[idx].Name.Value}, nil
+}
+
+// enumNamedBy returns the enum that expr names, as Color in Color.Red or lib.Color in
+// lib.Color.Red for an enum of an imported module, or nil if it names none.
+// A variable of the same name shadows the enum.
+func (i *Interpreter) enumNamedBy(expr Expression) *enumType {
+	switch node := expr.(type) {
+	case *Identifier:
+		if _, isVar := i.env.Get(node.Value); isVar {
+			return nil
+		}
+		return i.env.typeRegistry().enums[node.Value]
+	case *MemberExpr:
+		ident, ok := node.Object.(*Identifier)
+		if !ok {
+			return nil
+		}
+		v, ok := i.env.Get(ident.Value)
+		if !ok {
+			return nil
+		}
+		mod, ok := v.Value.(*Module)
+		if !ok {
+			return nil
+		}
+		if _, isVar := mod.Env.Get(node.Member.Value); isVar {
+			return nil
+		}
+		return mod.Env.types.enums[node.Member.Value]
+	}
+	return nil
+}
+
+// convertEnum checks that a value is a member of the named enum of a module
+func convertEnum(module, typeName string, value any, pos *Position) (types.EnumValue, error) {
+	member, ok := value.(types.EnumValue)
+	if !ok || member.TypeName != typeName || member.Module != module {
+		return types.EnumValue{}, NewTypeMismatchErrorf(pos, "expected %s, got %s", typeName, getTypeString(value))
+	}
+	return member, nil
+}
+
+// compareEnumMembers compares a member with another member of the same enum.
+// Members have no order, so only == and != are supported.
+func compareEnumMembers(op TokenType, left types.EnumValue, right any, pos *Position) (any, error) {
+	r, ok := right.(types.EnumValue)
+	if !ok || r.TypeName != left.TypeName || r.Module != left.Module {
+		return nil, NewTypeMismatchErrorf(pos, "cannot compare %s with %s", left.TypeName, getTypeString(right))
+	}
+
+	switch op {
+	case EQ:
+		return left == r, nil
+	case NEQ:
+		return left != r, nil
+	default:
+		return nil, NewRuntimeError(pos, "operator %s not supported for enum %s", op, left.TypeName)
+	}
+}
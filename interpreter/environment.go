@@ -0,0 +1,63 @@
+package interpreter
+
+import "wtf-script/types"
+
+// Environment holds the variables of a single scope and links to its enclosing scope
+type Environment struct {
+	store map[string]types.Variable
+	names []string // the declared names in declaration order, so reroll all draws reproducibly
+	outer *Environment
+	types *typeRegistry // the struct and enum types of the file, only set on its top-level scope
+}
+
+// NewEnvironment creates an empty scope nested inside outer (nil for the global scope)
+func NewEnvironment(outer *Environment) *Environment {
+	return &Environment{
+		store: make(map[string]types.Variable),
+		outer: outer,
+	}
+}
+
+// Get looks a variable up in this scope and then in every enclosing scope
+func (e *Environment) Get(name string) (types.Variable, bool) {
+	for env := e; env != nil; env = env.outer {
+		if v, ok := env.store[name]; ok {
+			return v, true
+		}
+	}
+	return types.Variable{}, false
+}
+
+// typeRegistry returns the struct and enum types of the file this scope belongs to
+func (e *Environment) typeRegistry() *typeRegistry {
+	for e.outer != nil {
+		e = e.outer
+	}
+	return e.types
+}
+
+// Has reports whether a variable is declared in this scope, ignoring enclosing scopes
+func (e *Environment) Has(name string) bool {
+	_, ok := e.store[name]
+	return ok
+}
+
+// Define creates or replaces a variable in this scope
+func (e *Environment) Define(name string, v types.Variable) {
+	if _, ok := e.store[name]; !ok {
+		e.names = append(e.names, name)
+	}
+	e.store[name] = v
+}
+
+// Set updates an existing variable in the nearest scope that declares it.
+// It returns false if the variable is not declared anywhere.
+func (e *Environment) Set(name string, v types.Variable) bool {
+	for env := e; env != nil; env = env.outer {
+		if _, ok := env.store[name]; ok {
+			env.store[name] = v
+			return true
+		}
+	}
+	return false
+}
@@ -2,6 +2,17 @@ package interpreter
 
 import "fmt"
 
-func PrintError(line, column int, errorType, msg string) string {
-	return fmt.Sprintf("[Line %d, Col %d] %s error: %s", line, column, errorType, msg)
+func PrintError(pos *Position, errorType, msg string) string {
+	return fmt.Sprintf("%s %s error: %s", formatPosition(pos), errorType, msg)
+}
+
+func PrintWarning(pos *Position, msg string) string {
+	return fmt.Sprintf("%s warning: %s", formatPosition(pos), msg)
+}
+
+func formatPosition(pos *Position) string {
+	if pos.File != "" {
+		return fmt.Sprintf("[%s, Line %d, Col %d]", pos.File, pos.Line, pos.Column)
+	}
+	return fmt.Sprintf("[Line %d, Col %d]", pos.Line, pos.Column)
 }
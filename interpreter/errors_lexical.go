@@ -8,7 +8,7 @@ type LexicalError struct {
 }
 
 func (e *LexicalError) Error() string {
-	return PrintError(e.Line, e.Column, "lexical", e.Msg)
+	return PrintError(e.Position, "lexical", e.Msg)
 }
 
 func NewLexicalError(pos *Position, format string, args ...any) *LexicalError {
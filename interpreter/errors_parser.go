@@ -10,7 +10,7 @@ type ParserError struct {
 }
 
 func (e *ParserError) Error() string {
-	return PrintError(e.Line, e.Column, "parser", e.Msg)
+	return PrintError(e.Position, "parser", e.Msg)
 }
 
 func NewParserError(pos *Position, format string, args ...any) *ParserError {
@@ -20,52 +20,68 @@ func NewParserError(pos *Position, format string, args ...any) *ParserError {
 	}
 }
 
+// ParserWarning reports a likely mistake that does not stop the program from running
+type ParserWarning struct {
+	*Position
+	Msg string
+}
+
+func (w *ParserWarning) String() string {
+	return PrintWarning(w.Position, w.Msg)
+}
+
+func NewParserWarning(pos *Position, format string, args ...any) *ParserWarning {
+	return &ParserWarning{
+		Position: pos,
+		Msg:      fmt.Sprintf(format, args...),
+	}
+}
+
 func NewIllegalTokenError(node *Token) *ParserError {
 	return &ParserError{
-		Position: &Position{
-			Line:   node.Line,
-			Column: node.Column,
-		},
-		Msg: fmt.Sprintf("Illegal token: %s", node.Literal),
+		Position: node.Position(),
+		Msg:      fmt.Sprintf("Illegal token: %s", node.Literal),
 	}
 }
 
 func NewExpectedTokenError(node *Token, expected TokenType) *ParserError {
 	return &ParserError{
-		Position: &Position{
-			Line:   node.Line,
-			Column: node.Column,
-		},
-		Msg: fmt.Sprintf("expected next token to be %s, got %s instead", expected, node.Type),
+		Position: node.Position(),
+		Msg:      fmt.Sprintf("expected next token to be %s, got %s instead", expected, node.Type),
 	}
 }
 
 func NewNoPrefixParseFnError(node *Token, t TokenType) *ParserError {
 	return &ParserError{
-		Position: &Position{
-			Line:   node.Line,
-			Column: node.Column,
-		},
-		Msg: fmt.Sprintf("no prefix parse function for %s found", t),
+		Position: node.Position(),
+		Msg:      fmt.Sprintf("no prefix parse function for %s found", t),
 	}
 }
 
 func NewIntegerParseError(node *Token) *ParserError {
 	return &ParserError{
-		Position: &Position{
-			Line:   node.Line,
-			Column: node.Column,
-		},
-		Msg: fmt.Sprintf("could not parse %q as integer", node.Literal),
+		Position: node.Position(),
+		Msg:      fmt.Sprintf("could not parse %q as integer", node.Literal),
 	}
 }
 
 func NewFloatParseError(node *Token) *ParserError {
 	return &ParserError{
-		Position: &Position{
-			Line:   node.Line,
-			Column: node.Column,
-		},
-		Msg: fmt.Sprintf("could not parse %q as float", node.Literal),
+		Position: node.Position(),
+		Msg:      fmt.Sprintf("could not parse %q as float", node.Literal),
+	}
+}
+
+func NewUintParseError(node *Token) *ParserError {
+	return &ParserError{
+		Position: node.Position(),
+		Msg:      fmt.Sprintf("could not parse %q as uint", node.Literal),
+	}
+}
+
+func NewPercentParseError(node *Token) *ParserError {
+	return &ParserError{
+		Position: node.Position(),
+		Msg:      fmt.Sprintf("could not parse %q as percent, it must be between 0%% and 100%%", node.Literal),
 	}
 }
@@ -2,42 +2,123 @@ package interpreter
 
 import (
 	"fmt"
+	"strings"
+)
+
+// ErrorKind classifies runtime errors, scripts see it as the kind field of a caught error
+type ErrorKind string
+
+const (
+	ErrorKindRuntime            ErrorKind = "runtime"
+	ErrorKindDivisionByZero     ErrorKind = "division_by_zero"
+	ErrorKindTypeMismatch       ErrorKind = "type_mismatch"
+	ErrorKindUndefined          ErrorKind = "undefined"
+	ErrorKindRedeclaration      ErrorKind = "redeclaration"
+	ErrorKindConstAssignment    ErrorKind = "const_assignment"
+	ErrorKindVolatileAssignment ErrorKind = "volatile_assignment"
+	ErrorKindInvalidRange       ErrorKind = "invalid_range"
+	ErrorKindInvalidValue       ErrorKind = "invalid_value"
+	ErrorKindIndexOutOfBounds   ErrorKind = "index_out_of_bounds"
+	ErrorKindKeyNotFound        ErrorKind = "key_not_found"
+	ErrorKindInvalidCall        ErrorKind = "invalid_call"
+	ErrorKindThrown             ErrorKind = "thrown"
 )
 
 type RuntimeError struct {
 	*Position
-	Msg string
+	Kind ErrorKind
+	Msg  string
 }
 
 func (e *RuntimeError) Error() string {
-	return PrintError(e.Line, e.Column, "runtime", e.Msg)
+	return PrintError(e.Position, "runtime", e.Msg)
 }
 
 // NewRuntimeError creates a generic runtime error
 func NewRuntimeError(pos *Position, format string, args ...any) *RuntimeError {
 	return &RuntimeError{
 		Position: pos,
+		Kind:     ErrorKindRuntime,
 		Msg:      fmt.Sprintf(format, args...),
 	}
 }
 
 func NewIdentifierNotFoundError(ident *Identifier) *RuntimeError {
 	return &RuntimeError{
-		Position: &Position{ident.Token.Line, ident.Token.Column},
+		Position: ident.Token.Position(),
+		Kind:     ErrorKindUndefined,
 		Msg:      fmt.Sprintf("identifier not found: %s", ident.Value),
 	}
 }
 
 func NewVariableNotDefinedError(ident *Identifier) *RuntimeError {
 	return &RuntimeError{
-		Position: &Position{ident.Token.Line, ident.Token.Column},
+		Position: ident.Token.Position(),
+		Kind:     ErrorKindUndefined,
 		Msg:      fmt.Sprintf("variable not defined: %s", ident.Value),
 	}
 }
 
+func NewUnknownFieldError(field *Identifier, typeName string) *RuntimeError {
+	return &RuntimeError{
+		Position: field.Token.Position(),
+		Kind:     ErrorKindUndefined,
+		Msg:      fmt.Sprintf("%s has no field %s", typeName, field.Value),
+	}
+}
+
+func NewUnknownMemberError(member *Identifier, mod *Module) *RuntimeError {
+	return &RuntimeError{
+		Position: member.Token.Position(),
+		Kind:     ErrorKindUndefined,
+		Msg:      fmt.Sprintf("module %s has no member %s", mod.File, member.Value),
+	}
+}
+
+func NewUnknownEnumMemberError(member *Identifier, enum string) *RuntimeError {
+	return &RuntimeError{
+		Position: member.Token.Position(),
+		Kind:     ErrorKindUndefined,
+		Msg:      fmt.Sprintf("enum %s has no member %s", enum, member.Value),
+	}
+}
+
+func NewConstAssignmentError(ident *Identifier) *RuntimeError {
+	return &RuntimeError{
+		Position: ident.Token.Position(),
+		Kind:     ErrorKindConstAssignment,
+		Msg:      fmt.Sprintf("cannot assign to constant: %s", ident.Value),
+	}
+}
+
+func NewConstRerollError(ident *Identifier) *RuntimeError {
+	return &RuntimeError{
+		Position: ident.Token.Position(),
+		Kind:     ErrorKindConstAssignment,
+		Msg:      fmt.Sprintf("cannot reroll constant: %s", ident.Value),
+	}
+}
+
+func NewVolatileAssignmentError(ident *Identifier) *RuntimeError {
+	return &RuntimeError{
+		Position: ident.Token.Position(),
+		Kind:     ErrorKindVolatileAssignment,
+		Msg:      fmt.Sprintf("cannot assign to volatile variable: %s", ident.Value),
+	}
+}
+
+func NewRedeclarationError(ident *Identifier) *RuntimeError {
+	return &RuntimeError{
+		Position: ident.Token.Position(),
+		Kind:     ErrorKindRedeclaration,
+		Msg:      fmt.Sprintf("variable already declared in this scope: %s", ident.Value),
+	}
+}
+
 func NewDivisionByZeroError(pos *Position) *RuntimeError {
 	return &RuntimeError{
 		Position: pos,
+		Kind:     ErrorKindDivisionByZero,
 		Msg:      "division by zero",
 	}
 }
@@ -45,41 +126,72 @@ func NewDivisionByZeroError(pos *Position) *RuntimeError {
 func NewTypeMismatchError(pos *Position, expected, actual any) *RuntimeError {
 	return &RuntimeError{
 		Position: pos,
+		Kind:     ErrorKindTypeMismatch,
 		Msg:      fmt.Sprintf("type mismatch: %T and %T", expected, actual),
 	}
 }
 
+// NewTypeMismatchErrorf creates a type mismatch error with a custom description
+func NewTypeMismatchErrorf(pos *Position, format string, args ...any) *RuntimeError {
+	return &RuntimeError{
+		Position: pos,
+		Kind:     ErrorKindTypeMismatch,
+		Msg:      "type mismatch: " + fmt.Sprintf(format, args...),
+	}
+}
+
 func NewUnknownOperatorError(pos *Position, op TokenType, left, right any) *RuntimeError {
 	return &RuntimeError{
 		Position: pos,
+		Kind:     ErrorKindTypeMismatch,
 		Msg:      fmt.Sprintf("unknown operator or type: %v %s %v", left, op, right),
 	}
 }
 
 func NewUnknownUnaryOperatorError(node *UnaryExpr, right any) *RuntimeError {
 	return &RuntimeError{
-		Position: &Position{node.Token.Line, node.Token.Column},
+		Position: node.Token.Position(),
+		Kind:     ErrorKindTypeMismatch,
 		Msg:      fmt.Sprintf("unknown unary operator: %s %v", node.Operator, right),
 	}
 }
 
 func NewFunctionNotFoundError(node *CallExpr, name string) *RuntimeError {
 	return &RuntimeError{
-		Position: &Position{node.Token.Line, node.Token.Column},
+		Position: node.Token.Position(),
+		Kind:     ErrorKindUndefined,
 		Msg:      fmt.Sprintf("function not found: %s", name),
 	}
 }
 
-func NewInvalidFunctionCallError(node *CallExpr, reason string) *RuntimeError {
+func NewInvalidFunctionCallError(pos *Position, reason string) *RuntimeError {
 	return &RuntimeError{
-		Position: &Position{node.Token.Line, node.Token.Column},
+		Position: pos,
+		Kind:     ErrorKindInvalidCall,
 		Msg:      fmt.Sprintf("invalid function call: %s", reason),
 	}
 }
 
+func NewIndexOutOfBoundsError(pos *Position, index any, length int) *RuntimeError {
+	return &RuntimeError{
+		Position: pos,
+		Kind:     ErrorKindIndexOutOfBounds,
+		Msg:      fmt.Sprintf("index out of bounds: %v (length %d)", index, length),
+	}
+}
+
+func NewKeyNotFoundError(pos *Position, key any) *RuntimeError {
+	return &RuntimeError{
+		Position: pos,
+		Kind:     ErrorKindKeyNotFound,
+		Msg:      fmt.Sprintf("key not found: %v", key),
+	}
+}
+
 func NewInvalidRangeError(pos *Position, reason string) *RuntimeError {
 	return &RuntimeError{
 		Position: pos,
+		Kind:     ErrorKindInvalidRange,
 		Msg:      reason,
 	}
 }
@@ -87,6 +199,7 @@ func NewInvalidRangeError(pos *Position, reason string) *RuntimeError {
 func NewNegativeUintAssignmentError(pos *Position, value int64) *RuntimeError {
 	return &RuntimeError{
 		Position: pos,
+		Kind:     ErrorKindInvalidValue,
 		Msg:      fmt.Sprintf("cannot assign %d to uint: value must be non-negative", value),
 	}
 }
@@ -94,6 +207,56 @@ func NewNegativeUintAssignmentError(pos *Position, value int64) *RuntimeError {
 func NewInvalidUnofloatAssignmentError(pos *Position, value float64) *RuntimeError {
 	return &RuntimeError{
 		Position: pos,
+		Kind:     ErrorKindInvalidValue,
 		Msg:      fmt.Sprintf("cannot assign %f to unofloat: value out of range [0.0, 1.0]", value),
 	}
 }
+
+// NewInvalidDistributionError reports distribution parameters that cannot be sampled, e.g. normal(0, -1)
+func NewInvalidDistributionError(pos *Position, format string, args ...any) *RuntimeError {
+	return &RuntimeError{
+		Position: pos,
+		Kind:     ErrorKindInvalidValue,
+		Msg:      fmt.Sprintf(format, args...),
+	}
+}
+
+// NewThrownError creates the error raised by a throw statement
+func NewThrownError(pos *Position, msg string) *RuntimeError {
+	return &RuntimeError{
+		Position: pos,
+		Kind:     ErrorKindThrown,
+		Msg:      msg,
+	}
+}
+
+// AssertionError reports a failed assert statement. Unlike a RuntimeError it
+// cannot be caught, so a failed assertion always stops the script.
+type AssertionError struct {
+	*Position
+	Expr     string             // the asserted condition, from Node.String()
+	Operands []AssertionOperand // the evaluated operands of the condition
+	Msg      string             // the optional message given to assert
+}
+
+// AssertionOperand is an evaluated part of an asserted condition
+type AssertionOperand struct {
+	Expr  string
+	Value any
+}
+
+func (e *AssertionError) Error() string {
+	var out strings.Builder
+	out.WriteString(e.Expr + " failed")
+	if e.Msg != "" {
+		out.WriteString(": " + e.Msg)
+	}
+	if len(e.Operands) > 0 {
+		operands := make([]string, len(e.Operands))
+		for idx, op := range e.Operands {
+			operands[idx] = fmt.Sprintf("%s = %v", op.Expr, op.Value)
+		}
+		out.WriteString(" [" + strings.Join(operands, ", ") + "]")
+	}
+	return PrintError(e.Position, "assertion", out.String())
+}
@@ -0,0 +1,108 @@
+package interpreter
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// decodeEscape decodes the escape sequence at the start of s, which follows a
+// backslash. It returns the decoded rune and how many bytes of s it used.
+//
+//	\n \t \r \\ \" \$ and \u{1F3B2} with one to six hex digits
+func decodeEscape(s string) (rune, int, error) {
+	if s == "" {
+		return 0, 0, fmt.Errorf("unterminated escape sequence")
+	}
+
+	switch s[0] {
+	case 'n':
+		return '\n', 1, nil
+	case 't':
+		return '\t', 1, nil
+	case 'r':
+		return '\r', 1, nil
+	case '\\':
+		return '\\', 1, nil
+	case '"':
+		return '"', 1, nil
+	case '$':
+		return '$', 1, nil
+	case 'u':
+		end := strings.IndexByte(s, '}')
+		if len(s) < 2 || s[1] != '{' || end < 0 {
+			return 0, 0, fmt.Errorf("invalid unicode escape, expected \\u{...}")
+		}
+		digits := s[2:end]
+		if len(digits) == 0 || len(digits) > 6 {
+			return 0, 0, fmt.Errorf("invalid unicode escape \\u{%s}: expected 1 to 6 hex digits", digits)
+		}
+		code, err := strconv.ParseUint(digits, 16, 32)
+		if err != nil || !utf8.ValidRune(rune(code)) {
+			return 0, 0, fmt.Errorf("invalid unicode escape \\u{%s}", digits)
+		}
+		return rune(code), end + 1, nil
+	}
+
+	ch, _ := utf8.DecodeRuneInString(s)
+	if ch == '\n' {
+		return 0, 0, fmt.Errorf("invalid escape sequence at the end of a line")
+	}
+	return 0, 0, fmt.Errorf("invalid escape sequence \\%c", ch)
+}
+
+// unescape decodes the escape sequences of a string body checked by the lexer,
+// invalid sequences are kept as they are
+func unescape(s string) string {
+	if !strings.ContainsRune(s, '\\') {
+		return s
+	}
+
+	var out strings.Builder
+	for idx := 0; idx < len(s); idx++ {
+		if s[idx] != '\\' {
+			out.WriteByte(s[idx])
+			continue
+		}
+		ch, size, err := decodeEscape(s[idx+1:])
+		if err != nil {
+			out.WriteByte(s[idx])
+			continue
+		}
+		out.WriteRune(ch)
+		idx += size
+	}
+	return out.String()
+}
+
+// stringBody returns the text between the quotes of a string literal, where it
+// starts in the literal and whether it is a raw string. A multi-line string
+// drops the line break that directly follows its opening quotes.
+func stringBody(literal string) (body string, offset int, raw bool) {
+	open, close := `"`, `"`
+	switch {
+	case strings.HasPrefix(literal, "`"):
+		open, close, raw = "`", "`", true
+	case strings.HasPrefix(literal, `"""`):
+		open, close = `"""`, `"""`
+	}
+
+	body = strings.TrimPrefix(literal, open)
+	// An unterminated literal has no closing quote, the lexer reports it
+	if len(literal) >= len(open)+len(close) {
+		body = strings.TrimSuffix(body, close)
+	}
+	offset = len(open)
+
+	if open == `"""` {
+		for _, newline := range []string{"\r\n", "\n"} {
+			if strings.HasPrefix(body, newline) {
+				body = body[len(newline):]
+				offset += len(newline)
+				break
+			}
+		}
+	}
+	return body, offset, raw
+}
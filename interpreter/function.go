@@ -0,0 +1,44 @@
+package interpreter
+
+import (
+	"strings"
+)
+
+// Function is the runtime value of a function declaration or literal.
+// It closes over the environment it was created in.
+type Function struct {
+	Name    string // empty for anonymous function literals
+	Literal *FunctionLiteral
+	Env     *Environment
+}
+
+// Arity returns the number of declared parameters
+func (f *Function) Arity() int {
+	return len(f.Literal.Parameters)
+}
+
+// String returns the function signature, e.g. func add(int a, int b) int
+func (f *Function) String() string {
+	params := []string{}
+	for _, p := range f.Literal.Parameters {
+		params = append(params, strings.TrimSuffix(p.String(), ";"))
+	}
+
+	signature := "func"
+	if f.Name != "" {
+		signature += " " + f.Name
+	}
+	signature += "(" + strings.Join(params, ", ") + ")"
+	if f.Literal.ReturnType != "" {
+		signature += " " + f.Literal.returnTypeString()
+	}
+	return signature
+}
+
+// displayName is used in error messages
+func (f *Function) displayName() string {
+	if f.Name != "" {
+		return f.Name
+	}
+	return "anonymous function"
+}
@@ -1,9 +1,11 @@
 package interpreter
 
 import (
+	"errors"
 	"fmt"
+	"math"
 	"math/rand"
-	"strconv"
+	"strings"
 	"time"
 	"wtf-script/builtins"
 	"wtf-script/config"
@@ -11,10 +13,21 @@ import (
 )
 
 type Interpreter struct {
-	Variables map[string]types.Variable
+	Variables map[string]types.Variable // global scope
 	Builtins  map[string]types.IBuiltinFunc
 	Rand      *rand.Rand
 	Config    *config.Config
+
+	globals   *Environment
+	env       *Environment // current scope
+	callDepth int
+	callSite  *Position // position of the builtin call being executed, used by CallFunction
+
+	types         map[string]*typeRegistry // struct and enum types by module path, "" for the main program
+	instantiating map[string]bool          // struct types currently being instantiated, to reject self containment
+
+	modules   map[string]*Module // imported modules by absolute path, every file is evaluated once
+	importing []*Module          // files currently being evaluated, innermost last, to detect import cycles
 }
 
 func (i *Interpreter) GetConfig() *config.Config {
@@ -22,9 +35,10 @@ func (i *Interpreter) GetConfig() *config.Config {
 }
 
 func (i *Interpreter) GenerateRandomString(n int, charset string) string {
-	b := make([]byte, n)
+	chars := []rune(charset)
+	b := make([]rune, n)
 	for j := range b {
-		b[j] = charset[i.Rand.Intn(len(charset))]
+		b[j] = chars[i.Rand.Intn(len(chars))]
 	}
 	return string(b)
 }
@@ -37,6 +51,25 @@ func (i *Interpreter) LogError(format string, args ...any) {
 	LogError(format, args...)
 }
 
+// CallFunction lets builtins invoke function values passed to them as arguments
+func (i *Interpreter) CallFunction(fn any, args []any) (any, error) {
+	pos := i.callSite
+	if pos == nil {
+		pos = &Position{}
+	}
+
+	f, ok := fn.(*Function)
+	if !ok {
+		return nil, NewInvalidFunctionCallError(pos, fmt.Sprintf("%s is not a function", getTypeString(fn)))
+	}
+
+	strict := make([]bool, len(args))
+	for idx := range strict {
+		strict[idx] = true
+	}
+	return i.callFunction(f, args, strict, pos)
+}
+
 func NewInterpreter(cfg *config.Config) *Interpreter {
 	if cfg == nil {
 		cfg = &config.DefaultConfig
@@ -46,7 +79,14 @@ func NewInterpreter(cfg *config.Config) *Interpreter {
 		Builtins:  make(map[string]types.IBuiltinFunc),
 		Rand:      rand.New(rand.NewSource(time.Now().UnixNano())),
 		Config:    cfg,
+
+		types:         make(map[string]*typeRegistry),
+		instantiating: make(map[string]bool),
+
+		modules: make(map[string]*Module),
 	}
+	i.globals = &Environment{store: i.Variables, types: i.newTypeRegistry("")}
+	i.env = i.globals
 
 	builtins.RegisterBuiltins(func(name string, fn types.IBuiltinFunc) {
 		i.Builtins[name] = fn
@@ -54,22 +94,48 @@ func NewInterpreter(cfg *config.Config) *Interpreter {
 	return i
 }
 
+// Execute runs code that does not come from a file, its imports are resolved
+// relative to the working directory
 func (i *Interpreter) Execute(code string) {
-	l := NewLexer("main", code)
+	i.ExecuteFile("", code)
+}
+
+// ExecuteFile runs the source code of a file. Diagnostics carry the file name
+// and imports are resolved relative to the directory of the file.
+// Errors are logged and also returned, so callers can report a failed run.
+func (i *Interpreter) ExecuteFile(filename, code string) error {
+	l := NewLexer(filename, code)
 	p := NewParser(l)
 	program := p.ParseProgram()
 
-	if len(p.Errors()) > 0 {
-		for _, msg := range p.Errors() {
-			LogError("%s", msg)
+	for _, w := range p.warnings {
+		LogWarning("%s", w)
+	}
+	if len(p.errors) > 0 {
+		errs := make([]error, len(p.errors))
+		for idx, err := range p.errors {
+			LogError("%s", err)
+			errs[idx] = err
+		}
+		return errors.Join(errs...)
+	}
+
+	if filename != "" {
+		// The main file takes part in cycle detection, so importing it back is reported
+		main, err := newModule(filename, i.globals)
+		if err != nil {
+			LogError("%s", err)
+			return err
 		}
-		return
+		i.importing = append(i.importing, main)
+		defer func() { i.importing = i.importing[:len(i.importing)-1] }()
 	}
 
 	_, err := i.Evaluate(program)
 	if err != nil {
 		LogError("%s", err)
 	}
+	return err
 }
 
 func (i *Interpreter) Evaluate(node Node) (any, error) {
@@ -85,10 +151,52 @@ func (i *Interpreter) Evaluate(node Node) (any, error) {
 		return i.evalVarDecl(node)
 	case *AssignStmt:
 		return i.evalAssignStmt(node)
+	case *IndexAssignStmt:
+		return i.evalIndexAssignStmt(node)
+	case *MemberAssignStmt:
+		return i.evalMemberAssignStmt(node)
+	case *StructDecl:
+		return i.evalStructDecl(node)
+	case *EnumDecl:
+		return i.evalEnumDecl(node)
+	case *TypeDecl:
+		return i.evalTypeDecl(node)
+	case *NamedBound:
+		return i.evalNamedBound(node)
+	case *ImportStmt:
+		return i.evalImportStmt(node)
+	case *MatchStmt:
+		return i.evalMatchStmt(node)
+	case *ChooseStmt:
+		return i.evalChooseStmt(node)
+	case *TryStmt:
+		return i.evalTryStmt(node)
+	case *ThrowStmt:
+		return i.evalThrowStmt(node)
+	case *AssertStmt:
+		return i.evalAssertStmt(node)
+	case *RerollStmt:
+		return i.evalRerollStmt(node)
 	case *BlockStmt:
 		return i.evalBlockStmt(node)
 	case *IfStmt:
 		return i.evalIfStmt(node)
+	case *WhileStmt:
+		return i.evalWhileStmt(node)
+	case *ForStmt:
+		return i.evalForStmt(node)
+	case *RepeatStmt:
+		return i.evalRepeatStmt(node)
+	case *FuncDecl:
+		return i.evalFuncDecl(node)
+	case *ReturnStmt:
+		return i.evalReturnStmt(node)
+	case *ForInStmt:
+		return i.evalForInStmt(node)
+	case *BreakStmt:
+		return nil, &breakSignal{node.Token.Position()}
+	case *ContinueStmt:
+		return nil, &continueSignal{node.Token.Position()}
 
 	// Expressions
 	case *Identifier:
@@ -97,19 +205,37 @@ func (i *Interpreter) Evaluate(node Node) (any, error) {
 		return node.Value, nil
 	case *FloatLiteral:
 		return node.Value, nil
+	case *UintLiteral:
+		return node.Value, nil
+	case *PercentLiteral:
+		return types.UnofloatType(node.Value), nil
 	case *BooleanLiteral:
 		return node.Value, nil
 	case *StringLiteral:
-		if unquoted, err := strconv.Unquote(node.Value); err == nil {
-			return unquoted, nil
-		}
 		return node.Value, nil
 	case *BinaryExpr:
 		return i.evalBinaryExpr(node)
+	case *ConditionalExpr:
+		return i.evalConditionalExpr(node)
+	case *TemplateString:
+		return i.evalTemplateString(node)
 	case *UnaryExpr:
 		return i.evalUnaryExpr(node)
 	case *CallExpr:
 		return i.evalCallExpr(node)
+	case *FunctionLiteral:
+		return &Function{Literal: node, Env: i.env}, nil
+	case *ArrayLiteral:
+		return i.evalArrayLiteral(node, types.Unknown)
+	case *MapLiteral:
+		return i.evalMapLiteral(node, types.Unknown, types.Unknown)
+	case *IndexExpr:
+		return i.evalIndexExpr(node)
+	case *MemberExpr:
+		return i.evalMemberExpr(node)
+	case *RangeExpr:
+		return nil, NewRuntimeError(node.Token.Position(),
+			"range %s can only be used in a for loop", node.String())
 	}
 
 	return nil, nil
@@ -128,15 +254,23 @@ func (i *Interpreter) evalProgram(program *Program) (any, error) {
 }
 
 func (i *Interpreter) evalIdentifier(node *Identifier) (any, error) {
-	if val, ok := i.Variables[node.Value]; ok {
-		return val.Value, nil
+	if v, ok := i.env.Get(node.Value); ok {
+		return readVariable(v)
 	}
 	return nil, NewIdentifierNotFoundError(node)
 }
 
+// readVariable returns the value of a variable, which for a volatile variable is a fresh draw
+func readVariable(v types.Variable) (any, error) {
+	if v.Volatile {
+		return v.Generator()
+	}
+	return v.Value, nil
+}
+
 func isLiteral(node Node) bool {
 	switch node.(type) {
-	case *IntegerLiteral, *FloatLiteral, *StringLiteral, *BooleanLiteral:
+	case *IntegerLiteral, *UintLiteral, *FloatLiteral, *PercentLiteral, *StringLiteral, *BooleanLiteral:
 		return true
 	}
 	return false
@@ -186,109 +320,197 @@ func (i *Interpreter) validateUintAssignment(value any, shouldValidateStrict boo
 }
 
 func (i *Interpreter) evalVarDecl(node *VarDecl) (any, error) {
+	// Shadowing a variable from an enclosing scope is fine, redeclaring it in the same scope is not
+	if i.env.Has(node.Name.Value) {
+		return nil, NewRedeclarationError(node.Name)
+	}
+
 	var val any
+	var generate func() (any, error)
 
-	if node.RangeMin != nil && node.RangeMax != nil {
-		// Handles: int(0, 100) x;
-		minVal, err := i.Evaluate(node.RangeMin)
+	if node.Value == nil {
+		// Handles: int x;, int(0, 100) x;, float ~ normal(100, 15) iq;, User u;, Color c;,
+		// int(1, 6)[10] dice; and map(1, 5)[string]int m;. The variable keeps the generator, so reroll can draw again
+		var err error
+		generate, err = i.declGenerator(node)
 		if err != nil {
 			return nil, err
 		}
-		maxVal, err := i.Evaluate(node.RangeMax)
+		if generate == nil {
+			return nil, NewRuntimeError(node.Token.Position(),
+				"func variable %s must be initialized", node.Name.Value)
+		}
+		if val, err = generate(); err != nil {
+			return nil, err
+		}
+	} else if node.Array {
+		// Handles: int[] xs = [1, 2]; and int[2] xs = ys;
+		arr, err := i.evalArrayDecl(node)
 		if err != nil {
 			return nil, err
 		}
-
-		var errVal error
-		pos := &Position{Line: node.Token.Line, Column: node.Token.Column}
-		val, errVal = i.randomValueInRange(node.Type, minVal, maxVal, pos)
-		if errVal != nil {
-			return nil, errVal
+		val = arr
+	} else if node.KeyType != "" {
+		// Handles: map[string]int m = {"a": 1};
+		m, err := i.evalMapDecl(node)
+		if err != nil {
+			return nil, err
 		}
-	} else if node.Value != nil {
-		// Handles: int x = 5;
+		val = m
+	} else {
+		// Handles: int x = 5; and Dice d = 3;, where the value replaces the random draw like an argument does
 		evaluated, err := i.Evaluate(node.Value)
 		if err != nil {
 			return nil, err
 		}
 
-		expectedType := types.VarType(varTypeFromToken(node.Type))
 		shouldValidateStrict := isLiteral(node.Value) || isIdentifier(node.Value)
 
-		// Special handling for unofloat and uint assignment validation
-		pos := &Position{Line: node.Token.Line, Column: node.Token.Column}
-		switch expectedType {
-		case types.Unofloat:
-			validatedVal, err := i.validateUnofloatAssignment(evaluated, shouldValidateStrict, pos)
-			if err != nil {
-				return nil, err
-			}
-			evaluated = validatedVal
-		case types.Uint:
-			validatedVal, err := i.validateUintAssignment(evaluated, shouldValidateStrict, pos)
-			if err != nil {
-				return nil, err
-			}
-			evaluated = validatedVal
+		pos := node.Token.Position()
+		val, err = i.convertForDecl(node, evaluated, shouldValidateStrict, pos)
+		if err != nil {
+			return nil, err
 		}
+	}
+
+	i.env.Define(node.Name.Value, types.Variable{
+		Type:      declaredType(node),
+		TypeName:  declaredTypeName(node),
+		Value:     val,
+		Const:     node.Const,
+		Volatile:  node.Volatile,
+		Generator: generate,
+	})
+	return val, nil
+}
+
+// declaredType returns the variable type of a declaration or parameter, which is
+// types.Array or types.Map for collections regardless of their element types
+func declaredType(decl *VarDecl) types.VarType {
+	if decl.Array {
+		return types.Array
+	}
+	if decl.KeyType != "" {
+		return types.Map
+	}
+	return types.VarType(varTypeFromToken(decl.Type))
+}
+
+// declaredTypeName returns the named type of a declaration, e.g. Dice or Dice[],
+// or "" when it was declared with a plain type
+func declaredTypeName(decl *VarDecl) string {
+	if decl.TypeName == "" || !decl.Array {
+		return decl.TypeName
+	}
+	return decl.TypeName + "[]"
+}
 
-		err = i.checkTypeCompatibility(expectedType, evaluated, pos)
+// convertForDecl converts a value for a declaration or parameter, see convertForAssignment
+func (i *Interpreter) convertForDecl(decl *VarDecl, value any, shouldValidateStrict bool, pos *Position) (any, error) {
+	valueType := types.VarType(varTypeFromToken(decl.Type))
+	if decl.Array {
+		arr, err := i.convertArray(valueType, value, pos)
+		if err != nil {
+			return nil, err
+		}
+		return arr, nil
+	}
+	if decl.KeyType != "" {
+		m, err := i.convertMap(types.VarType(varTypeFromToken(decl.KeyType)), valueType, value, pos)
+		if err != nil {
+			return nil, err
+		}
+		return m, nil
+	}
+	if decl.Type == IDENT {
+		s, err := convertStruct(i.env.typeRegistry().module, decl.Token.Literal, value, pos)
 		if err != nil {
 			return nil, err
 		}
+		return s, nil
+	}
+	if decl.Type == ENUM {
+		return convertEnum(i.env.typeRegistry().module, decl.Token.Literal, value, pos)
+	}
+	return i.convertForAssignment(valueType, value, shouldValidateStrict, pos)
+}
 
-		val = castToType(expectedType, evaluated)
-	} else {
-		// Handles: int x; (random default)
-		val = i.randomValue(node.Type)
+// convertForAssignment validates a value against the declared type of its target
+// and casts it to that type. It applies the same strictness rules to declarations,
+// assignments, function arguments and return values.
+func (i *Interpreter) convertForAssignment(expectedType types.VarType, value any, shouldValidateStrict bool, pos *Position) (any, error) {
+	// Special handling for unofloat and uint assignment validation
+	switch expectedType {
+	case types.Unofloat:
+		validatedVal, err := i.validateUnofloatAssignment(value, shouldValidateStrict, pos)
+		if err != nil {
+			return nil, err
+		}
+		value = validatedVal
+	case types.Uint:
+		validatedVal, err := i.validateUintAssignment(value, shouldValidateStrict, pos)
+		if err != nil {
+			return nil, err
+		}
+		value = validatedVal
 	}
 
-	i.Variables[node.Name.Value] = types.Variable{
-		Type:  types.VarType(varTypeFromToken(node.Type)),
-		Value: val,
+	if err := i.checkTypeCompatibility(expectedType, value, pos); err != nil {
+		return nil, err
 	}
-	return val, nil
+
+	return castToType(expectedType, value), nil
 }
 
 func (i *Interpreter) evalAssignStmt(node *AssignStmt) (any, error) {
+	// Constants are rejected before the value is evaluated, so PI = f() fails without calling f
+	if v, ok := i.env.Get(node.Name.Value); ok && v.Const {
+		return nil, NewConstAssignmentError(node.Name)
+	}
+	if v, ok := i.env.Get(node.Name.Value); ok && v.Volatile && i.Config.Volatile.Assignment != config.VolatileAssignPin {
+		return nil, NewVolatileAssignmentError(node.Name)
+	}
+
 	val, err := i.Evaluate(node.Value)
 	if err != nil {
 		return nil, err
 	}
 
-	if v, ok := i.Variables[node.Name.Value]; ok {
-		pos := &Position{Line: node.Token.Line, Column: node.Token.Column}
-		err = i.checkTypeCompatibility(v.Type, val, pos)
-		if err != nil {
-			return nil, err
-		}
-
+	if v, ok := i.env.Get(node.Name.Value); ok {
+		pos := node.Token.Position()
 		shouldValidateStrict := isLiteral(node.Value) || isIdentifier(node.Value)
 
-		// Special handling for unofloat and uint assignment validation
-		switch v.Type {
-		case types.Unofloat:
-			validatedVal, err := i.validateUnofloatAssignment(val, shouldValidateStrict, pos)
-			if err != nil {
-				return nil, err
-			}
-			val = validatedVal
-		case types.Uint:
-			validatedVal, err := i.validateUintAssignment(val, shouldValidateStrict, pos)
-			if err != nil {
-				return nil, err
-			}
-			val = validatedVal
+		// Array and map variables keep the element types they were declared with
+		var converted any
+		switch current := v.Value.(type) {
+		case *types.ArrayValue:
+			converted, err = i.convertArray(current.ElemType, val, pos)
+		case *types.MapValue:
+			converted, err = i.convertMap(current.KeyType, current.ValueType, val, pos)
+		case *types.StructValue:
+			converted, err = convertStruct(current.Module, current.TypeName, val, pos)
+		case types.EnumValue:
+			converted, err = convertEnum(current.Module, current.TypeName, val, pos)
+		default:
+			converted, err = i.convertForAssignment(v.Type, val, shouldValidateStrict, pos)
+		}
+		if err != nil {
+			return nil, err
 		}
 
-		v.Value = castToType(v.Type, val)
-		i.Variables[node.Name.Value] = v
+		// With the pin assignment mode the assigned value sticks, so the variable stops being volatile
+		v.Value, v.Volatile = converted, false
+		i.env.Set(node.Name.Value, v)
 		return val, nil
 	}
 	return nil, NewVariableNotDefinedError(node.Name)
 }
 
 func (i *Interpreter) evalBinaryExpr(node *BinaryExpr) (any, error) {
+	if node.Operator == IN {
+		return i.evalInExpr(node)
+	}
+
 	// Handle logical operators with short-circuit evaluation
 	if node.Operator == AND || node.Operator == OR {
 		left, err := i.Evaluate(node.Left)
@@ -325,7 +547,7 @@ func (i *Interpreter) evalBinaryExpr(node *BinaryExpr) (any, error) {
 		return nil, err
 	}
 
-	pos := &Position{Line: node.Token.Line, Column: node.Token.Column}
+	pos := node.Token.Position()
 	return i.applyOp(node.Operator, left, right, pos)
 }
 
@@ -419,6 +641,10 @@ func defaultComparisonOp[T int64 | uint64 | float64 | string](op TokenType, l, r
 }
 
 func (i *Interpreter) applyComparisonOp(op TokenType, left, right any, pos *Position) (any, error) {
+	if l, ok := left.(types.EnumValue); ok {
+		return compareEnumMembers(op, l, right, pos)
+	}
+
 	leftVal, rightVal, err := i.coerceValues(left, right, pos)
 	if err != nil {
 		return nil, err
@@ -567,33 +793,74 @@ func (i *Interpreter) evalUnaryExpr(node *UnaryExpr) (any, error) {
 }
 
 func (i *Interpreter) evalCallExpr(node *CallExpr) (any, error) {
+	pos := node.Token.Position()
+
 	// Evaluate arguments
 	args := []any{}
+	strict := []bool{}
 	for _, a := range node.Arguments {
 		val, err := i.Evaluate(a)
 		if err != nil {
 			return nil, err
 		}
 		args = append(args, val)
+		strict = append(strict, isLiteral(a) || isIdentifier(a))
 	}
 
-	// Function identifier
-	ident, ok := node.Function.(*Identifier)
-	if !ok {
-		return nil, NewInvalidFunctionCallError(node, "function expression must be an identifier")
+	// Builtins take precedence and cannot be shadowed by user-defined functions
+	if ident, ok := node.Function.(*Identifier); ok {
+		if fn, ok := i.Builtins[ident.Value]; ok {
+			// Values do not carry their named type, so typeof(d) reads it from the variable
+			if ident.Value == builtins.TYPEOF && len(node.Arguments) == 1 {
+				if arg, ok := node.Arguments[0].(*Identifier); ok {
+					if v, ok := i.env.Get(arg.Value); ok && v.TypeName != "" {
+						return v.TypeName, nil
+					}
+				}
+			}
+
+			// call(f, ...) runs f like a direct call, so its errors and failed asserts reach the caller
+			if ident.Value == builtins.CALL && len(args) > 0 {
+				if f, ok := args[0].(*Function); ok {
+					return i.callFunction(f, args[1:], strict[1:], pos)
+				}
+			}
+
+			prevCallSite := i.callSite
+			i.callSite = pos
+			val := fn(args, i)
+			i.callSite = prevCallSite
+			return val, nil
+		}
+
+		if _, ok := i.env.Get(ident.Value); !ok {
+			return nil, NewFunctionNotFoundError(node, ident.Value)
+		}
 	}
 
-	if fn, ok := i.Builtins[ident.Value]; ok {
-		val := fn(args, i)
-		return val, nil
+	callee, err := i.Evaluate(node.Function)
+	if err != nil {
+		return nil, err
 	}
 
-	return nil, NewFunctionNotFoundError(node, ident.Value)
+	fn, ok := callee.(*Function)
+	if !ok {
+		return nil, NewInvalidFunctionCallError(pos, fmt.Sprintf("%s is not a function", node.Function.String()))
+	}
+
+	return i.callFunction(fn, args, strict, pos)
 }
 
+// evalBlockStmt runs a block in its own scope nested inside the current one
 func (i *Interpreter) evalBlockStmt(block *BlockStmt) (any, error) {
+	return i.evalInScope(NewEnvironment(i.env), func() (any, error) {
+		return i.evalStatements(block.Statements)
+	})
+}
+
+func (i *Interpreter) evalStatements(statements []Statement) (any, error) {
 	var result any
-	for _, statement := range block.Statements {
+	for _, statement := range statements {
 		val, err := i.Evaluate(statement)
 		if err != nil {
 			return nil, err
@@ -603,60 +870,1230 @@ func (i *Interpreter) evalBlockStmt(block *BlockStmt) (any, error) {
 	return result, nil
 }
 
+// evalInScope runs fn with env as the current scope and restores the previous scope afterwards
+func (i *Interpreter) evalInScope(env *Environment, fn func() (any, error)) (any, error) {
+	prevEnv := i.env
+	i.env = env
+	defer func() { i.env = prevEnv }()
+	return fn()
+}
+
 func (i *Interpreter) evalIfStmt(node *IfStmt) (any, error) {
 	var condition bool
-	pos := &Position{Line: node.Token.Line, Column: node.Token.Column}
+	pos := node.Token.Position()
 	if node.Token.Type == IFRAND {
 		// ifrand statement
+		randCond, err := i.evalRandomCondition(node.Condition, "ifrand", pos)
+		if err != nil {
+			return nil, err
+		}
+		condition = randCond
+	} else {
+		// Regular if statement
+		boolCond, err := i.evalCondition(node.Condition, "if", pos)
+		if err != nil {
+			return nil, err
+		}
+		condition = boolCond
+	}
+
+	if condition {
+		return i.Evaluate(node.Consequence)
+	} else if node.Alternative != nil {
+		return i.Evaluate(node.Alternative)
+	}
+
+	return nil, nil
+}
+
+// evalTemplateString concatenates the parts of an interpolated string, values are formatted like print does
+func (i *Interpreter) evalTemplateString(node *TemplateString) (any, error) {
+	var out strings.Builder
+	for _, part := range node.Parts {
+		val, err := i.Evaluate(part)
+		if err != nil {
+			return nil, err
+		}
+		out.WriteString(types.FormatValue(val))
+	}
+	return out.String(), nil
+}
+
+// evalConditionalExpr evaluates cond ? a : b and ifrand(p) ? a : b.
+// Only the selected branch is evaluated, like the right side of && and ||.
+func (i *Interpreter) evalConditionalExpr(node *ConditionalExpr) (any, error) {
+	var condition bool
+	var err error
+	pos := node.Token.Position()
+	if node.Token.Type == IFRAND {
+		condition, err = i.evalRandomCondition(node.Condition, "ifrand", pos)
+	} else {
+		condition, err = i.evalCondition(node.Condition, "ternary", pos)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if condition {
+		return i.Evaluate(node.Consequence)
+	}
+	return i.Evaluate(node.Alternative)
+}
+
+// evalCondition evaluates a loop or branch condition, which must be a bool
+func (i *Interpreter) evalCondition(cond Expression, keyword string, pos *Position) (bool, error) {
+	condVal, err := i.Evaluate(cond)
+	if err != nil {
+		return false, err
+	}
+
+	boolCond, ok := condVal.(bool)
+	if !ok {
+		return false, NewRuntimeError(pos, "%s condition must evaluate to bool, got %T", keyword, condVal)
+	}
+	return boolCond, nil
+}
+
+// evalRandomCondition draws a Bernoulli trial for ifrand-style constructs.
+// A nil probability falls back to DefaultIfrandProbability.
+func (i *Interpreter) evalRandomCondition(prob Expression, keyword string, pos *Position) (bool, error) {
+	if prob == nil {
+		return i.Rand.Float64() < DefaultIfrandProbability, nil
+	}
+
+	probVal, err := i.Evaluate(prob)
+	if err != nil {
+		return false, err
+	}
+
+	var probability float64
+	switch p := probVal.(type) {
+	case float64:
+		probability = p
+	case int64:
+		probability = float64(p)
+	case uint64:
+		probability = float64(p)
+	case types.UnofloatType:
+		probability = float64(p)
+	default:
+		return false, NewRuntimeError(pos, "%s probability must be a number, got %T", keyword, probVal)
+	}
+
+	if probability < UnofloatMin || probability > UnofloatMax {
+		return false, NewRuntimeError(pos, "%s probability must be between 0 and 1, got %f", keyword, probability)
+	}
+
+	return i.Rand.Float64() < probability, nil
+}
+
+// evalLoopBody runs a single iteration of a loop body.
+// It consumes break and continue signals and reports whether the loop should stop.
+func (i *Interpreter) evalLoopBody(body *BlockStmt) (bool, error) {
+	_, err := i.Evaluate(body)
+	switch err.(type) {
+	case nil, *continueSignal:
+		return false, nil
+	case *breakSignal:
+		return true, nil
+	}
+	return true, err
+}
+
+func (i *Interpreter) evalWhileStmt(node *WhileStmt) (any, error) {
+	pos := node.Token.Position()
+	for {
+		var condition bool
+		var err error
+		if node.Token.Type == WHILERAND {
+			condition, err = i.evalRandomCondition(node.Condition, "whilerand", pos)
+		} else {
+			condition, err = i.evalCondition(node.Condition, "while", pos)
+		}
+		if err != nil {
+			return nil, err
+		}
+		if !condition {
+			return nil, nil
+		}
+
+		stop, err := i.evalLoopBody(node.Body)
+		if err != nil {
+			return nil, err
+		}
+		if stop {
+			return nil, nil
+		}
+	}
+}
+
+func (i *Interpreter) evalForStmt(node *ForStmt) (any, error) {
+	// The init clause gets its own scope, so the loop variable does not outlive the loop
+	return i.evalInScope(NewEnvironment(i.env), func() (any, error) {
+		return i.evalForLoop(node)
+	})
+}
+
+func (i *Interpreter) evalForLoop(node *ForStmt) (any, error) {
+	pos := node.Token.Position()
+
+	if node.Init != nil {
+		if _, err := i.Evaluate(node.Init); err != nil {
+			return nil, err
+		}
+	}
+
+	for {
 		if node.Condition != nil {
-			// ifrand(probability)
-			probVal, err := i.Evaluate(node.Condition)
+			condition, err := i.evalCondition(node.Condition, "for", pos)
 			if err != nil {
 				return nil, err
 			}
-
-			var probability float64
-			switch p := probVal.(type) {
-			case float64:
-				probability = p
-			case int64:
-				probability = float64(p)
-			case uint64:
-				probability = float64(p)
-			case types.UnofloatType:
-				probability = float64(p)
-			default:
-				return nil, NewRuntimeError(pos, "ifrand probability must be a number, got %T", probVal)
+			if !condition {
+				return nil, nil
 			}
+		}
+
+		stop, err := i.evalLoopBody(node.Body)
+		if err != nil {
+			return nil, err
+		}
+		if stop {
+			return nil, nil
+		}
 
-			if probability < UnofloatMin || probability > UnofloatMax {
-				return nil, NewRuntimeError(pos, "ifrand probability must be between 0 and 1, got %f", probability)
+		if node.Post != nil {
+			if _, err := i.Evaluate(node.Post); err != nil {
+				return nil, err
 			}
+		}
+	}
+}
 
-			condition = i.Rand.Float64() < probability
-		} else {
-			// ifrand without probability (default 0.5)
-			condition = i.Rand.Float64() < DefaultIfrandProbability
+func (i *Interpreter) evalForInStmt(node *ForInStmt) (any, error) {
+	pos := node.Token.Position()
+
+	var varType types.VarType
+	var next func() (any, bool)
+	if rangeExpr, ok := node.Iterable.(*RangeExpr); ok {
+		var err error
+		varType, next, err = i.rangeIterator(rangeExpr)
+		if err != nil {
+			return nil, err
 		}
 	} else {
-		// Regular if statement
-		condVal, err := i.Evaluate(node.Condition)
+		iterable, err := i.Evaluate(node.Iterable)
 		if err != nil {
 			return nil, err
 		}
 
-		boolCond, ok := condVal.(bool)
-		if !ok {
-			return nil, NewRuntimeError(pos, "if condition must evaluate to bool, got %T", condVal)
+		var elements []any
+		switch v := iterable.(type) {
+		case *types.ArrayValue:
+			// Elements are visited in order, assignments made by the body are seen by later iterations
+			varType, elements = v.ElemType, v.Elements
+		case *types.MapValue:
+			// Maps are iterated by key in insertion order, keys added by the body are not visited
+			varType, elements = v.KeyType, v.Keys()
+		default:
+			return nil, NewRuntimeError(pos, "cannot iterate over %s", node.Iterable.String())
+		}
+
+		idx := 0
+		next = func() (any, bool) {
+			if idx >= len(elements) {
+				return nil, false
+			}
+			idx++
+			return elements[idx-1], true
 		}
-		condition = boolCond
 	}
 
-	if condition {
-		return i.Evaluate(node.Consequence)
-	} else if node.Alternative != nil {
-		return i.Evaluate(node.Alternative)
+	for value, ok := next(); ok; value, ok = next() {
+		// Every iteration binds the loop variable in a fresh scope, so closures capture its current value
+		iteration := NewEnvironment(i.env)
+		iteration.Define(node.Variable.Value, types.Variable{Type: varType, Value: value})
+
+		var stop bool
+		_, err := i.evalInScope(iteration, func() (any, error) {
+			var err error
+			stop, err = i.evalLoopBody(node.Body)
+			return nil, err
+		})
+		if err != nil {
+			return nil, err
+		}
+		if stop {
+			break
+		}
+	}
+	return nil, nil
+}
+
+// evalMatchStmt evaluates the subject once and runs the body of the first arm that matches it
+func (i *Interpreter) evalMatchStmt(node *MatchStmt) (any, error) {
+	subject, err := i.Evaluate(node.Subject)
+	if err != nil {
+		return nil, err
 	}
 
+	for _, arm := range node.Arms {
+		matched, err := i.matchPattern(arm, subject)
+		if err != nil {
+			return nil, err
+		}
+		if matched {
+			return i.Evaluate(arm.Body)
+		}
+	}
 	return nil, nil
 }
+
+// matchPattern reports whether subject matches the pattern of an arm. A literal matches
+// like subject == literal and a range a..b like subject >= a && subject <= b.
+func (i *Interpreter) matchPattern(arm *MatchArm, subject any) (bool, error) {
+	if arm.Pattern == nil {
+		return true, nil
+	}
+	pos := arm.Token.Position()
+
+	if rangeExpr, ok := arm.Pattern.(*RangeExpr); ok {
+		start, err := i.Evaluate(rangeExpr.Start)
+		if err != nil {
+			return false, err
+		}
+		end, err := i.Evaluate(rangeExpr.End)
+		if err != nil {
+			return false, err
+		}
+
+		aboveStart, err := i.applyComparisonOp(GTE, subject, start, pos)
+		if err != nil || aboveStart != true {
+			return false, err
+		}
+		belowEnd, err := i.applyComparisonOp(LTE, subject, end, pos)
+		return belowEnd == true, err
+	}
+
+	val, err := i.Evaluate(arm.Pattern)
+	if err != nil {
+		return false, err
+	}
+	equal, err := i.applyComparisonOp(EQ, subject, val, pos)
+	return equal == true, err
+}
+
+// evalChooseStmt picks one arm with a probability proportional to its weight.
+// Every weight is evaluated before the single draw, so all of them are validated.
+func (i *Interpreter) evalChooseStmt(node *ChooseStmt) (any, error) {
+	weights := make([]float64, len(node.Arms))
+	for idx, arm := range node.Arms {
+		weight, err := i.evalWeight("choose", arm.Weight, arm.Token.Position())
+		if err != nil {
+			return nil, err
+		}
+		weights[idx] = weight
+	}
+	return i.Evaluate(node.Arms[i.pickWeighted(weights)].Body)
+}
+
+// evalWeight evaluates the weight of a choose arm or an enum member, which must be a positive number
+func (i *Interpreter) evalWeight(owner string, expr Expression, pos *Position) (float64, error) {
+	val, err := i.Evaluate(expr)
+	if err != nil {
+		return 0, err
+	}
+
+	weight, ok := toFloat64(val)
+	if !ok {
+		return 0, NewRuntimeError(pos, "%s weight must be a number, got %s", owner, getTypeString(val))
+	}
+	if weight <= 0 || math.IsNaN(weight) || math.IsInf(weight, 0) {
+		return 0, NewRuntimeError(pos, "%s weight must be positive, got %v", owner, val)
+	}
+	return weight, nil
+}
+
+// pickWeighted draws an index with a probability proportional to its weight
+func (i *Interpreter) pickWeighted(weights []float64) int {
+	total := 0.0
+	for _, weight := range weights {
+		total += weight
+	}
+
+	draw := i.Rand.Float64() * total
+	for idx, weight := range weights {
+		if draw < weight {
+			return idx
+		}
+		draw -= weight
+	}
+	// Rounding can leave the draw just past the last weight
+	return len(weights) - 1
+}
+
+func (i *Interpreter) evalRepeatStmt(node *RepeatStmt) (any, error) {
+	pos := node.Token.Position()
+
+	var count uint64
+	if node.Token.Type == REPEATRAND {
+		// repeatrand(min, max): the count is drawn once from min up to, but excluding, max
+		// like int(min, max)
+		minVal, err := i.evalRepeatCount(node.Min, pos)
+		if err != nil {
+			return nil, err
+		}
+		maxVal, err := i.evalRepeatCount(node.Max, pos)
+		if err != nil {
+			return nil, err
+		}
+		if err := checkRange(minVal, maxVal, pos); err != nil {
+			return nil, err
+		}
+		// Counts are at most MaxInt64, so the span fits a uint64 but not always an int64
+		count = minVal + i.randomUint64n(maxVal-minVal)
+	} else {
+		val, err := i.evalRepeatCount(node.Count, pos)
+		if err != nil {
+			return nil, err
+		}
+		count = val
+	}
+
+	for n := uint64(0); n < count; n++ {
+		stop, err := i.evalLoopBody(node.Body)
+		if err != nil {
+			return nil, err
+		}
+		if stop {
+			break
+		}
+	}
+	return nil, nil
+}
+
+// evalRepeatCount evaluates an iteration count, which must be a non-negative integer
+func (i *Interpreter) evalRepeatCount(expr Expression, pos *Position) (uint64, error) {
+	val, err := i.Evaluate(expr)
+	if err != nil {
+		return 0, err
+	}
+
+	switch v := val.(type) {
+	case int64:
+		if v >= 0 {
+			return uint64(v), nil
+		}
+	case uint64:
+		if v <= math.MaxInt64 {
+			return v, nil
+		}
+	}
+	return 0, NewRuntimeError(pos, "repeat count must be a non-negative integer, got %v", val)
+}
+
+func (i *Interpreter) evalFuncDecl(node *FuncDecl) (any, error) {
+	pos := node.Name.Token.Position()
+	if _, ok := i.Builtins[node.Name.Value]; ok {
+		return nil, NewRuntimeError(pos, "cannot redeclare builtin function: %s", node.Name.Value)
+	}
+	if i.env.Has(node.Name.Value) {
+		return nil, NewRuntimeError(pos, "function already declared: %s", node.Name.Value)
+	}
+
+	fn := &Function{Name: node.Name.Value, Literal: node.Function, Env: i.env}
+	i.env.Define(node.Name.Value, types.Variable{Type: types.Func, Value: fn})
+	return fn, nil
+}
+
+func (i *Interpreter) evalReturnStmt(node *ReturnStmt) (any, error) {
+	signal := &returnSignal{Position: node.Token.Position()}
+	if node.Value != nil {
+		val, err := i.Evaluate(node.Value)
+		if err != nil {
+			return nil, err
+		}
+		signal.Value = val
+		signal.Strict = isLiteral(node.Value) || isIdentifier(node.Value)
+	}
+	return nil, signal
+}
+
+// callFunction invokes a function value in a fresh call frame nested in the scope it closes over.
+// Missing arguments for ranged parameters are drawn at random from the range.
+// strict reports, per argument, whether it was a literal or variable (see validateUintAssignment).
+func (i *Interpreter) callFunction(fn *Function, args []any, strict []bool, pos *Position) (any, error) {
+	lit := fn.Literal
+	name := fn.displayName()
+
+	if len(args) > len(lit.Parameters) {
+		return nil, NewInvalidFunctionCallError(pos,
+			fmt.Sprintf("%s expects at most %d arguments, got %d", name, len(lit.Parameters), len(args)))
+	}
+	if i.callDepth >= MaxCallDepth {
+		return nil, NewRuntimeError(pos, "maximum call depth of %d exceeded in %s", MaxCallDepth, name)
+	}
+
+	// Parameters and the return value are converted in the frame, so like the body
+	// they see the types of the file that declares the function
+	frame := NewEnvironment(fn.Env)
+	prevEnv := i.env
+	i.env = frame
+	defer func() { i.env = prevEnv }()
+
+	for idx, param := range lit.Parameters {
+		var val any
+		var generate func() (any, error)
+		if idx < len(args) {
+			converted, err := i.convertForDecl(param, args[idx], strict[idx], pos)
+			if err != nil {
+				return nil, err
+			}
+			val = converted
+		} else if param.RangeMin != nil || param.Distribution != nil {
+			// An omitted parameter is drawn like a declaration, so it can be rerolled like one
+			var err error
+			if generate, err = i.declGenerator(param); err != nil {
+				return nil, err
+			}
+			if val, err = generate(); err != nil {
+				return nil, err
+			}
+		} else {
+			return nil, NewInvalidFunctionCallError(pos,
+				fmt.Sprintf("missing argument for parameter %s of %s", param.Name.Value, name))
+		}
+
+		frame.Define(param.Name.Value, types.Variable{
+			Type:      declaredType(param),
+			TypeName:  declaredTypeName(param),
+			Value:     val,
+			Generator: generate,
+		})
+	}
+
+	// The body shares the frame with the parameters, so redeclaring a parameter is an error
+	i.callDepth++
+	_, err := i.evalStatements(lit.Body.Statements)
+	i.callDepth--
+
+	var result *returnSignal
+	switch sig := err.(type) {
+	case nil:
+	case *returnSignal:
+		result = sig
+	case *breakSignal:
+		return nil, NewRuntimeError(sig.Position, "break outside of loop")
+	case *continueSignal:
+		return nil, NewRuntimeError(sig.Position, "continue outside of loop")
+	default:
+		return nil, err
+	}
+
+	if lit.ReturnType == "" {
+		if result != nil && result.Value != nil {
+			return nil, NewRuntimeError(result.Position, "function %s does not return a value", name)
+		}
+		return nil, nil
+	}
+
+	if result == nil || result.Value == nil {
+		return nil, NewRuntimeError(pos, "function %s must return a value of type %s", name, lit.returnTypeString())
+	}
+	returnDecl := &VarDecl{
+		Token:   Token{Type: lit.ReturnType, Literal: lit.ReturnName},
+		Type:    lit.ReturnType,
+		Array:   lit.ReturnsArr,
+		KeyType: lit.ReturnKey,
+	}
+	return i.convertForDecl(returnDecl, result.Value, result.Strict, result.Position)
+}
+
+// declGenerator returns a function that draws a value the way decl does without an initial value,
+// or nil for func variables, which cannot be random. Bounds, distribution parameters and array
+// sizes are evaluated once, so every draw of a generator follows the same declaration.
+func (i *Interpreter) declGenerator(decl *VarDecl) (func() (any, error), error) {
+	switch {
+	case decl.Array:
+		return i.arrayGenerator(decl)
+	case decl.KeyType != "":
+		return i.mapGenerator(decl)
+	default:
+		return i.valueGenerator(decl)
+	}
+}
+
+// valueGenerator returns a function that draws a single value of the declared type,
+// from its range or distribution if it has one
+func (i *Interpreter) valueGenerator(decl *VarDecl) (func() (any, error), error) {
+	pos := decl.Token.Position()
+	switch {
+	case decl.Type == FUNC:
+		return nil, nil
+	case decl.Type == IDENT:
+		// Every draw is a fresh instance: User u;
+		if decl.RangeMin != nil {
+			return nil, NewInvalidRangeError(pos, fmt.Sprintf("struct type %s does not take a range", decl.Token.Literal))
+		}
+		// Types resolve where the variable is declared, also when it is drawn again from another file
+		reg := i.env.typeRegistry()
+		return func() (any, error) {
+			instance, err := i.instantiateStruct(reg, decl.Token.Literal, pos)
+			if err != nil {
+				return nil, err
+			}
+			return instance, nil
+		}, nil
+	case decl.Type == ENUM:
+		reg := i.env.typeRegistry()
+		return func() (any, error) {
+			member, err := i.pickEnumMember(reg, decl.TypeName, pos)
+			if err != nil {
+				return nil, err
+			}
+			return member, nil
+		}, nil
+	case decl.RangeMin != nil || decl.Distribution != nil:
+		return i.rangedGenerator(decl)
+	default:
+		return func() (any, error) {
+			return i.randomValue(decl.Type), nil
+		}, nil
+	}
+}
+
+// arrayGenerator returns a function that fills a new array of the declared size,
+// drawing every element on its own
+func (i *Interpreter) arrayGenerator(decl *VarDecl) (func() (any, error), error) {
+	pos := decl.Token.Position()
+	elemType := types.VarType(varTypeFromToken(decl.Type))
+
+	size := 0
+	if decl.Size != nil {
+		n, err := i.evalArraySize(decl.Size, pos)
+		if err != nil {
+			return nil, err
+		}
+		size = n
+	}
+	if size == 0 {
+		return func() (any, error) {
+			return &types.ArrayValue{ElemType: elemType, Elements: make([]any, 0)}, nil
+		}, nil
+	}
+
+	draw, err := i.valueGenerator(decl)
+	if err != nil {
+		return nil, err
+	}
+	if draw == nil {
+		return nil, NewRuntimeError(pos, "func array %s must be initialized", decl.Name.Value)
+	}
+
+	return func() (any, error) {
+		arr := &types.ArrayValue{ElemType: elemType, Elements: make([]any, 0, size)}
+		for n := 0; n < size; n++ {
+			val, err := draw()
+			if err != nil {
+				return nil, err
+			}
+			arr.Elements = append(arr.Elements, val)
+		}
+		return arr, nil
+	}, nil
+}
+
+// rangedGenerator evaluates the range, charset or distribution of a declaration
+// once and returns a function that draws one value per call
+func (i *Interpreter) rangedGenerator(decl *VarDecl) (func() (any, error), error) {
+	pos := decl.Token.Position()
+	if decl.Charset != nil && decl.Type != TYPE_STRING {
+		return nil, NewInvalidRangeError(pos, "only string ranges take a charset")
+	}
+	if decl.Distribution != nil {
+		return i.distributionSampler(decl)
+	}
+
+	minVal, maxVal, err := i.evalRangeBounds(decl)
+	if err != nil {
+		return nil, err
+	}
+
+	if decl.Charset != nil {
+		val, err := i.Evaluate(decl.Charset)
+		if err != nil {
+			return nil, err
+		}
+		charset, ok := val.(string)
+		if !ok || charset == "" {
+			return nil, NewInvalidRangeError(pos, "charset must be a non-empty string")
+		}
+		return func() (any, error) {
+			return i.randomStringInRange(minVal, maxVal, charset, pos)
+		}, nil
+	}
+
+	return func() (any, error) {
+		return i.randomValueInRange(decl.Type, minVal, maxVal, pos)
+	}, nil
+}
+
+func (i *Interpreter) evalRangeBounds(decl *VarDecl) (any, any, error) {
+	minVal, err := i.Evaluate(decl.RangeMin)
+	if err != nil {
+		return nil, nil, err
+	}
+	if decl.RangeMax == nil {
+		// bool(p) has a probability and no max
+		return minVal, nil, nil
+	}
+	maxVal, err := i.Evaluate(decl.RangeMax)
+	if err != nil {
+		return nil, nil, err
+	}
+	return minVal, maxVal, nil
+}
+
+// evalArrayDecl builds the value of an array declaration with an initial value.
+// If the declaration has a size, the value must have exactly that many elements.
+func (i *Interpreter) evalArrayDecl(decl *VarDecl) (*types.ArrayValue, error) {
+	pos := decl.Token.Position()
+	elemType := types.VarType(varTypeFromToken(decl.Type))
+
+	size := -1
+	if decl.Size != nil {
+		n, err := i.evalArraySize(decl.Size, pos)
+		if err != nil {
+			return nil, err
+		}
+		size = n
+	}
+
+	var arr *types.ArrayValue
+	var err error
+	if literal, ok := decl.Value.(*ArrayLiteral); ok {
+		// Literal elements are converted straight to the declared type, so float[] xs = [1, 2.5] keeps 2.5
+		arr, err = i.evalArrayLiteral(literal, elemType)
+	} else {
+		var val any
+		if val, err = i.Evaluate(decl.Value); err == nil {
+			arr, err = i.convertArray(elemType, val, pos)
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	if size >= 0 && len(arr.Elements) != size {
+		return nil, NewRuntimeError(pos, "array %s expects %d elements, got %d", decl.Name.Value, size, len(arr.Elements))
+	}
+	return arr, nil
+}
+
+// evalArraySize evaluates the length of an array declaration, which must be a non-negative integer
+func (i *Interpreter) evalArraySize(expr Expression, pos *Position) (int, error) {
+	val, err := i.Evaluate(expr)
+	if err != nil {
+		return 0, err
+	}
+
+	var size uint64
+	switch v := val.(type) {
+	case int64:
+		if v < 0 {
+			return 0, NewRuntimeError(pos, "array size must be a non-negative integer, got %d", v)
+		}
+		size = uint64(v)
+	case uint64:
+		size = v
+	default:
+		return 0, NewRuntimeError(pos, "array size must be a non-negative integer, got %s", getTypeString(val))
+	}
+
+	if size > MaxArraySize {
+		return 0, NewRuntimeError(pos, "array size %d exceeds the maximum of %d", size, MaxArraySize)
+	}
+	return int(size), nil
+}
+
+// evalArrayLiteral evaluates the elements of an array literal and converts them to elemType.
+// If elemType is types.Unknown the first element decides it (FCFS), so [1, 2.5] is an int array.
+func (i *Interpreter) evalArrayLiteral(node *ArrayLiteral, elemType types.VarType) (*types.ArrayValue, error) {
+	pos := node.Token.Position()
+
+	elements := make([]any, 0, len(node.Elements))
+	for idx, el := range node.Elements {
+		val, err := i.Evaluate(el)
+		if err != nil {
+			return nil, err
+		}
+		if idx == 0 && elemType == types.Unknown {
+			elemType = valueType(val)
+		}
+
+		shouldValidateStrict := isLiteral(el) || isIdentifier(el)
+		converted, err := i.convertForAssignment(elemType, val, shouldValidateStrict, pos)
+		if err != nil {
+			return nil, err
+		}
+		elements = append(elements, converted)
+	}
+
+	return &types.ArrayValue{ElemType: elemType, Elements: elements}, nil
+}
+
+// convertArray converts an array value to an array of elemType. Arrays that already have
+// that element type are shared rather than copied, like any other reference value.
+func (i *Interpreter) convertArray(elemType types.VarType, value any, pos *Position) (*types.ArrayValue, error) {
+	arr, ok := value.(*types.ArrayValue)
+	if !ok {
+		return nil, NewTypeMismatchErrorf(pos, "expected %s[], got %s", elemType, getTypeString(value))
+	}
+	if arr.ElemType == elemType {
+		return arr, nil
+	}
+
+	converted := &types.ArrayValue{ElemType: elemType, Elements: make([]any, 0, len(arr.Elements))}
+	for _, el := range arr.Elements {
+		val, err := i.convertForAssignment(elemType, el, true, pos)
+		if err != nil {
+			return nil, err
+		}
+		converted.Elements = append(converted.Elements, val)
+	}
+	return converted, nil
+}
+
+func (i *Interpreter) evalIndexExpr(node *IndexExpr) (any, error) {
+	pos := node.Token.Position()
+
+	left, err := i.Evaluate(node.Left)
+	if err != nil {
+		return nil, err
+	}
+	index, err := i.Evaluate(node.Index)
+	if err != nil {
+		return nil, err
+	}
+
+	switch v := left.(type) {
+	case *types.ArrayValue:
+		idx, err := checkIndex(index, len(v.Elements), pos)
+		if err != nil {
+			return nil, err
+		}
+		return v.Elements[idx], nil
+	case *types.MapValue:
+		key, err := i.convertForAssignment(v.KeyType, index, true, pos)
+		if err != nil {
+			return nil, err
+		}
+		val, ok := v.Get(key)
+		if !ok {
+			return nil, NewKeyNotFoundError(pos, key)
+		}
+		return val, nil
+	case string:
+		runes := []rune(v)
+		idx, err := checkIndex(index, len(runes), pos)
+		if err != nil {
+			return nil, err
+		}
+		return string(runes[idx]), nil
+	}
+	return nil, NewRuntimeError(pos, "cannot index %s", getTypeString(left))
+}
+
+func (i *Interpreter) evalIndexAssignStmt(node *IndexAssignStmt) (any, error) {
+	pos := node.Target.Token.Position()
+
+	left, err := i.Evaluate(node.Target.Left)
+	if err != nil {
+		return nil, err
+	}
+	index, err := i.Evaluate(node.Target.Index)
+	if err != nil {
+		return nil, err
+	}
+
+	// Resolve the slot before evaluating the value, so xs[10] = f() fails without calling f
+	var elemType types.VarType
+	var store func(val any)
+	switch target := left.(type) {
+	case *types.ArrayValue:
+		idx, err := checkIndex(index, len(target.Elements), pos)
+		if err != nil {
+			return nil, err
+		}
+		elemType = target.ElemType
+		store = func(val any) { target.Elements[idx] = val }
+	case *types.MapValue:
+		// Assigning to a missing key inserts it
+		key, err := i.convertForAssignment(target.KeyType, index, true, pos)
+		if err != nil {
+			return nil, err
+		}
+		elemType = target.ValueType
+		store = func(val any) { target.Set(key, val) }
+	default:
+		return nil, NewRuntimeError(pos, "cannot assign to an element of %s", getTypeString(left))
+	}
+
+	val, err := i.Evaluate(node.Value)
+	if err != nil {
+		return nil, err
+	}
+	shouldValidateStrict := isLiteral(node.Value) || isIdentifier(node.Value)
+	converted, err := i.convertForAssignment(elemType, val, shouldValidateStrict, pos)
+	if err != nil {
+		return nil, err
+	}
+
+	store(converted)
+	return converted, nil
+}
+
+// checkIndex validates an index against the length of the indexed value
+func checkIndex(index any, length int, pos *Position) (int, error) {
+	switch v := index.(type) {
+	case int64:
+		if v >= 0 && v < int64(length) {
+			return int(v), nil
+		}
+	case uint64:
+		if v < uint64(length) {
+			return int(v), nil
+		}
+	default:
+		return 0, NewRuntimeError(pos, "index must be an integer, got %s", getTypeString(index))
+	}
+	return 0, NewIndexOutOfBoundsError(pos, index, length)
+}
+
+// evalMapDecl builds the value of a map declaration with an initial value.
+// A size range takes precedence and populates the map with random keys and values.
+func (i *Interpreter) evalMapDecl(decl *VarDecl) (*types.MapValue, error) {
+	pos := decl.Token.Position()
+	keyType := types.VarType(varTypeFromToken(decl.KeyType))
+	valueType := types.VarType(varTypeFromToken(decl.Type))
+
+	if decl.RangeMin != nil {
+		draw, err := i.mapGenerator(decl)
+		if err != nil {
+			return nil, err
+		}
+		m, err := draw()
+		if err != nil {
+			return nil, err
+		}
+		return m.(*types.MapValue), nil
+	}
+
+	if literal, ok := decl.Value.(*MapLiteral); ok {
+		return i.evalMapLiteral(literal, keyType, valueType)
+	}
+	val, err := i.Evaluate(decl.Value)
+	if err != nil {
+		return nil, err
+	}
+	return i.convertMap(keyType, valueType, val, pos)
+}
+
+// mapGenerator returns a function that creates a new map. Without a size range the map
+// starts out empty, with one it is populated with a random number of distinct random keys
+// drawn like int(min, max) and random values.
+func (i *Interpreter) mapGenerator(decl *VarDecl) (func() (any, error), error) {
+	pos := decl.Token.Position()
+	keyType := types.VarType(varTypeFromToken(decl.KeyType))
+	valueType := types.VarType(varTypeFromToken(decl.Type))
+
+	if decl.RangeMin == nil {
+		return func() (any, error) {
+			return types.NewMapValue(keyType, valueType), nil
+		}, nil
+	}
+
+	minVal, maxVal, err := i.evalRangeBounds(decl)
+	if err != nil {
+		return nil, err
+	}
+	if maxVal == nil {
+		return nil, NewInvalidRangeError(pos, "map size range needs a min and a max")
+	}
+
+	return func() (any, error) {
+		sizeVal, err := i.randomValueInRange(TYPE_INT, minVal, maxVal, pos)
+		if err != nil {
+			return nil, err
+		}
+		size := sizeVal.(int64)
+		if size < 0 || size > MaxMapSize {
+			return nil, NewRuntimeError(pos, "map size must be between 0 and %d, got %d", MaxMapSize, size)
+		}
+		if size > 0 && decl.Type == FUNC {
+			return nil, NewRuntimeError(pos, "map %s of func values cannot be populated at random", decl.Name.Value)
+		}
+
+		m := types.NewMapValue(keyType, valueType)
+		for attempts := 0; int64(m.Len()) < size; {
+			key := i.randomValue(decl.KeyType)
+			if m.Has(key) {
+				attempts++
+				if attempts > MaxRandomKeyAttempts {
+					return nil, NewRuntimeError(pos, "could not generate %d distinct %s keys for map %s",
+						size, m.KeyType, decl.Name.Value)
+				}
+				continue
+			}
+			attempts = 0
+			m.Set(key, i.randomValue(decl.Type))
+		}
+		return m, nil
+	}, nil
+}
+
+// evalMapLiteral evaluates the entries of a map literal and converts them to keyType and valType.
+// Unknown types are taken from the first entry (FCFS), like the element type of array literals.
+func (i *Interpreter) evalMapLiteral(node *MapLiteral, keyType, valType types.VarType) (*types.MapValue, error) {
+	pos := node.Token.Position()
+
+	var m *types.MapValue
+	for idx, keyExpr := range node.Keys {
+		valueExpr := node.Values[idx]
+
+		key, err := i.Evaluate(keyExpr)
+		if err != nil {
+			return nil, err
+		}
+		val, err := i.Evaluate(valueExpr)
+		if err != nil {
+			return nil, err
+		}
+
+		if m == nil {
+			if keyType == types.Unknown {
+				keyType = valueType(key)
+			}
+			if valType == types.Unknown {
+				valType = valueType(val)
+			}
+			if !isKeyType(keyType) {
+				return nil, NewRuntimeError(pos, "invalid map key type %s", getTypeString(key))
+			}
+			m = types.NewMapValue(keyType, valType)
+		}
+
+		key, err = i.convertForAssignment(keyType, key, isLiteral(keyExpr) || isIdentifier(keyExpr), pos)
+		if err != nil {
+			return nil, err
+		}
+		val, err = i.convertForAssignment(valType, val, isLiteral(valueExpr) || isIdentifier(valueExpr), pos)
+		if err != nil {
+			return nil, err
+		}
+		m.Set(key, val)
+	}
+
+	if m == nil {
+		m = types.NewMapValue(keyType, valType)
+	}
+	return m, nil
+}
+
+// convertMap converts a map value to a map with the given key and value types. Maps that
+// already have those types are shared rather than copied, like arrays.
+func (i *Interpreter) convertMap(keyType, valType types.VarType, value any, pos *Position) (*types.MapValue, error) {
+	m, ok := value.(*types.MapValue)
+	if !ok {
+		return nil, NewTypeMismatchErrorf(pos, "expected map[%s]%s, got %s", keyType, valType, getTypeString(value))
+	}
+	if m.KeyType == keyType && m.ValueType == valType {
+		return m, nil
+	}
+
+	converted := types.NewMapValue(keyType, valType)
+	for _, k := range m.Keys() {
+		v, _ := m.Get(k)
+		key, err := i.convertForAssignment(keyType, k, true, pos)
+		if err != nil {
+			return nil, err
+		}
+		val, err := i.convertForAssignment(valType, v, true, pos)
+		if err != nil {
+			return nil, err
+		}
+		converted.Set(key, val)
+	}
+	return converted, nil
+}
+
+// evalInExpr evaluates key in m, which reports whether a map contains a key
+func (i *Interpreter) evalInExpr(node *BinaryExpr) (any, error) {
+	pos := node.Token.Position()
+
+	key, err := i.Evaluate(node.Left)
+	if err != nil {
+		return nil, err
+	}
+	right, err := i.Evaluate(node.Right)
+	if err != nil {
+		return nil, err
+	}
+
+	m, ok := right.(*types.MapValue)
+	if !ok {
+		return nil, NewRuntimeError(pos, "in expects a map on the right, got %s", getTypeString(right))
+	}
+	key, err = i.convertForAssignment(m.KeyType, key, true, pos)
+	if err != nil {
+		return nil, err
+	}
+	return m.Has(key), nil
+}
+
+func (i *Interpreter) evalStructDecl(node *StructDecl) (any, error) {
+	reg := i.env.typeRegistry()
+	if _, ok := reg.structs[node.Name.Value]; ok {
+		return nil, NewRuntimeError(node.Name.Token.Position(),
+			"struct already declared: %s", node.Name.Value)
+	}
+	reg.structs[node.Name.Value] = &structType{Decl: node, Env: i.env}
+	return nil, nil
+}
+
+// instantiateStruct creates an instance of a struct type. Every field is declared in a scope
+// of its own, so it is initialized exactly like a variable declaration with the same syntax.
+func (i *Interpreter) instantiateStruct(reg *typeRegistry, name string, pos *Position) (*types.StructValue, error) {
+	def, ok := reg.structs[name]
+	if !ok {
+		return nil, NewRuntimeError(pos, "unknown type: %s", name)
+	}
+	key := reg.module + ":" + name
+	if i.instantiating[key] {
+		return nil, NewRuntimeError(pos, "struct %s cannot contain itself", name)
+	}
+	i.instantiating[key] = true
+	defer delete(i.instantiating, key)
+
+	scope := NewEnvironment(def.Env)
+	_, err := i.evalInScope(scope, func() (any, error) {
+		for _, field := range def.Decl.Fields {
+			if _, err := i.evalVarDecl(field); err != nil {
+				return nil, err
+			}
+		}
+		return nil, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	instance := types.NewStructValue(name)
+	instance.Module = reg.module
+	for _, field := range def.Decl.Fields {
+		instance.Set(field.Name.Value, scope.store[field.Name.Value].Value)
+	}
+	return instance, nil
+}
+
+// convertStruct checks that a value is an instance of the named struct type of a module.
+// Structs are never converted, so the instance itself is shared.
+func convertStruct(module, typeName string, value any, pos *Position) (*types.StructValue, error) {
+	instance, ok := value.(*types.StructValue)
+	if !ok || instance.TypeName != typeName || instance.Module != module {
+		return nil, NewTypeMismatchErrorf(pos, "expected %s, got %s", typeName, getTypeString(value))
+	}
+	return instance, nil
+}
+
+func (i *Interpreter) evalMemberExpr(node *MemberExpr) (any, error) {
+	pos := node.Token.Position()
+
+	if enum := i.enumNamedBy(node.Object); enum != nil {
+		member, ok := enum.member(node.Member.Value)
+		if !ok {
+			return nil, NewUnknownEnumMemberError(node.Member, enum.Decl.Name.Value)
+		}
+		return member, nil
+	}
+
+	object, err := i.Evaluate(node.Object)
+	if err != nil {
+		return nil, err
+	}
+
+	if mod, ok := object.(*Module); ok {
+		v, ok := mod.Env.Get(node.Member.Value)
+		if !ok {
+			return nil, NewUnknownMemberError(node.Member, mod)
+		}
+		return readVariable(v)
+	}
+
+	instance, ok := object.(*types.StructValue)
+	if !ok {
+		return nil, NewRuntimeError(pos, "cannot access field %s of %s", node.Member.Value, getTypeString(object))
+	}
+	val, ok := instance.Get(node.Member.Value)
+	if !ok {
+		return nil, NewUnknownFieldError(node.Member, instance.TypeName)
+	}
+	return val, nil
+}
+
+func (i *Interpreter) evalMemberAssignStmt(node *MemberAssignStmt) (any, error) {
+	pos := node.Token.Position()
+
+	object, err := i.Evaluate(node.Target.Object)
+	if err != nil {
+		return nil, err
+	}
+	if mod, ok := object.(*Module); ok {
+		return nil, NewRuntimeError(pos, "cannot assign to %s.%s: members of module %s are read-only",
+			node.Target.Object.String(), node.Target.Member.Value, mod.File)
+	}
+	instance, ok := object.(*types.StructValue)
+	if !ok {
+		return nil, NewRuntimeError(pos, "cannot assign to field %s of %s", node.Target.Member.Value, getTypeString(object))
+	}
+
+	def, ok := i.types[instance.Module].structs[instance.TypeName]
+	if !ok {
+		return nil, NewRuntimeError(pos, "cannot assign to %s.%s: fields of %s are read-only",
+			node.Target.Object.String(), node.Target.Member.Value, instance.TypeName)
+	}
+	field := def.field(node.Target.Member.Value)
+	if field == nil {
+		return nil, NewUnknownFieldError(node.Target.Member, instance.TypeName)
+	}
+	if field.Const {
+		return nil, NewConstAssignmentError(node.Target.Member)
+	}
+
+	val, err := i.Evaluate(node.Value)
+	if err != nil {
+		return nil, err
+	}
+
+	// Fields follow the same strictness rules as the declaration they were created from
+	shouldValidateStrict := isLiteral(node.Value) || isIdentifier(node.Value)
+	// like the initializers of the fields, the types they name resolve where the struct was declared
+	converted, err := i.evalInScope(def.Env, func() (any, error) {
+		return i.convertForFieldAssignment(field, instance, val, shouldValidateStrict, pos)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	instance.Set(field.Name.Value, converted)
+	return converted, nil
+}
+
+// convertForFieldAssignment converts a value for a field. Array and map fields keep the
+// element types of their current value, like array and map variables.
+func (i *Interpreter) convertForFieldAssignment(field *VarDecl, instance *types.StructValue, value any, shouldValidateStrict bool, pos *Position) (any, error) {
+	current, _ := instance.Get(field.Name.Value)
+	if arr, ok := current.(*types.ArrayValue); ok {
+		return i.convertArray(arr.ElemType, value, pos)
+	}
+	return i.convertForDecl(field, value, shouldValidateStrict, pos)
+}
@@ -1,6 +1,7 @@
 package interpreter
 
 import (
+	"fmt"
 	"math"
 	"wtf-script/types"
 )
@@ -19,6 +20,12 @@ func varTypeFromToken(t TokenType) int {
 		return int(types.Bool)
 	case TYPE_STRING:
 		return int(types.String)
+	case FUNC:
+		return int(types.Func)
+	case IDENT:
+		return int(types.Struct)
+	case ENUM:
+		return int(types.Enum)
 	default:
 		return int(types.Unknown)
 	}
@@ -46,35 +53,254 @@ func (i *Interpreter) randomValue(t TokenType) any {
 	return nil
 }
 
+// randomValueInRange draws a value for a ranged declaration such as int(1, 7) x.
+// Numbers are drawn from [min, max), string(min, max) draws the length of the
+// string like int(min, max) and bool(p) is true with probability p, so it has no max.
 func (i *Interpreter) randomValueInRange(t TokenType, min, max any, pos *Position) (any, error) {
+	if t == TYPE_BOOL {
+		return i.randomBoolWithProbability(min, max, pos)
+	}
+	if max == nil {
+		return nil, NewInvalidRangeError(pos, fmt.Sprintf("%s range needs a min and a max", types.VarType(varTypeFromToken(t))))
+	}
+
 	switch t {
 	case TYPE_INT:
 		minVal, ok1 := toInt64(min)
 		maxVal, ok2 := toInt64(max)
 		if !ok1 || !ok2 {
-			return nil, NewRuntimeError(pos, "invalid types for int range")
+			return nil, NewInvalidRangeError(pos, "invalid types for int range")
 		}
 
 		if err := checkRange(minVal, maxVal, pos); err != nil {
 			return nil, err
 		}
 
-		return i.Rand.Int63n(maxVal-minVal) + minVal, nil
+		// The difference is taken as uint64 so int(-2^62, 2^62) cannot overflow
+		return minVal + int64(i.randomUint64n(uint64(maxVal-minVal))), nil
 
-	case TYPE_FLOAT:
+	case TYPE_UINT:
+		if isNegative(min) || isNegative(max) {
+			return nil, NewInvalidRangeError(pos, "uint range cannot have negative bounds")
+		}
+		minVal, ok1 := toUint64(min)
+		maxVal, ok2 := toUint64(max)
+		if !ok1 || !ok2 {
+			return nil, NewInvalidRangeError(pos, "invalid types for uint range")
+		}
+
+		if err := checkRange(minVal, maxVal, pos); err != nil {
+			return nil, err
+		}
+
+		return minVal + i.randomUint64n(maxVal-minVal), nil
+
+	case TYPE_FLOAT, TYPE_UNOFLOAT:
 		minVal, ok1 := toFloat64(min)
 		maxVal, ok2 := toFloat64(max)
 		if !ok1 || !ok2 {
-			return nil, NewRuntimeError(pos, "invalid types for float range")
+			return nil, NewInvalidRangeError(pos, fmt.Sprintf("invalid types for %s range", types.VarType(varTypeFromToken(t))))
 		}
 
 		if err := checkRange(minVal, maxVal, pos); err != nil {
 			return nil, err
 		}
 
-		return i.Rand.Float64()*(maxVal-minVal) + minVal, nil
+		if t == TYPE_UNOFLOAT && (minVal < UnofloatMin || maxVal > UnofloatMax) {
+			return nil, NewInvalidRangeError(pos, "unofloat range must lie within 0 and 1")
+		}
+
+		val := i.Rand.Float64()*(maxVal-minVal) + minVal
+		if t == TYPE_UNOFLOAT {
+			return types.UnofloatType(val), nil
+		}
+		return val, nil
+
+	case TYPE_STRING:
+		return i.randomStringInRange(min, max, i.Config.Charset, pos)
 	}
-	return nil, nil
+
+	return nil, NewInvalidRangeError(pos, fmt.Sprintf("%s declarations cannot have a range", types.VarType(varTypeFromToken(t))))
+}
+
+// randomStringInRange draws string(min, max), whose length is drawn like int(min, max)
+func (i *Interpreter) randomStringInRange(min, max any, charset string, pos *Position) (any, error) {
+	minLen, ok1 := min.(int64)
+	maxLen, ok2 := max.(int64)
+	if !ok1 || !ok2 {
+		return nil, NewInvalidRangeError(pos, "string range takes the min and max length as ints")
+	}
+	if minLen < 0 || maxLen > MaxRandomStringLength {
+		return nil, NewInvalidRangeError(pos,
+			fmt.Sprintf("string length must be between 0 and %d", MaxRandomStringLength))
+	}
+
+	length, err := i.randomValueInRange(TYPE_INT, minLen, maxLen, pos)
+	if err != nil {
+		return nil, err
+	}
+	return i.GenerateRandomString(int(length.(int64)), charset), nil
+}
+
+// randomBoolWithProbability draws bool(p), which is true with probability p
+func (i *Interpreter) randomBoolWithProbability(p, max any, pos *Position) (bool, error) {
+	if max != nil {
+		return false, NewInvalidRangeError(pos, "bool range takes a single probability, e.g. bool(0.3)")
+	}
+	prob, ok := toFloat64(p)
+	if !ok {
+		return false, NewInvalidRangeError(pos, "bool probability must be a number")
+	}
+	if prob < 0 || prob > 1 || math.IsNaN(prob) {
+		return false, NewInvalidRangeError(pos, "bool probability must be between 0 and 1")
+	}
+	return i.Rand.Float64() < prob, nil
+}
+
+// randomUint64n returns a uniform value in [0, n) over the full uint64 range
+func (i *Interpreter) randomUint64n(n uint64) uint64 {
+	if n <= math.MaxInt64 {
+		return uint64(i.Rand.Int63n(int64(n)))
+	}
+	// More than half of all uint64 values are below n, so this rarely loops
+	for {
+		if v := i.Rand.Uint64(); v < n {
+			return v
+		}
+	}
+}
+
+// rangeIterator returns the values a for loop visits for a range.
+// Plain ranges (a..b) are inclusive, count upwards lazily and take their type from the start bound (FCFS),
+// while typed ranges (int(a, b)) have the same values as a declaration int(a, b) x; and visit
+// every value exactly once in random order.
+func (i *Interpreter) rangeIterator(node *RangeExpr) (types.VarType, func() (any, bool), error) {
+	pos := node.Token.Position()
+
+	startVal, err := i.Evaluate(node.Start)
+	if err != nil {
+		return types.Unknown, nil, err
+	}
+	endVal, err := i.Evaluate(node.End)
+	if err != nil {
+		return types.Unknown, nil, err
+	}
+
+	// Bounds are never truncated, so 1..5.5 is an error rather than 1..5
+	for _, bound := range []any{startVal, endVal} {
+		switch bound.(type) {
+		case int64, uint64:
+		default:
+			return types.Unknown, nil, NewTypeMismatchErrorf(pos, "range bounds must be int or uint, got %s", getTypeString(bound))
+		}
+	}
+
+	varType := types.VarType(varTypeFromToken(node.Type))
+	if node.Type == "" {
+		switch startVal.(type) {
+		case int64:
+			varType = types.Int
+		case uint64:
+			varType = types.Uint
+		}
+	}
+
+	// next counts from the start up to and including last. span is last - start, taken as
+	// uint64 so that it cannot overflow even for the whole int64 range.
+	var next func() (any, bool)
+	var span uint64
+	switch varType {
+	case types.Int:
+		start, ok1 := toInt64(startVal)
+		last, ok2 := toInt64(endVal)
+		if !ok1 || !ok2 {
+			return types.Unknown, nil, NewInvalidRangeError(pos, "invalid types for int range")
+		}
+		if node.Type != "" {
+			// Like a declaration, a typed range excludes its max
+			if err := checkRange(start, last, pos); err != nil {
+				return types.Unknown, nil, err
+			}
+			last--
+		}
+		span = uint64(last) - uint64(start)
+		cur, done := start, start > last
+		next = func() (any, bool) {
+			if done {
+				return nil, false
+			}
+			v := cur
+			if cur == last {
+				done = true
+			} else {
+				cur++
+			}
+			return v, true
+		}
+	case types.Uint:
+		start, ok1 := toUint64(startVal)
+		last, ok2 := toUint64(endVal)
+		if !ok1 || !ok2 {
+			return types.Unknown, nil, NewInvalidRangeError(pos, "invalid types for uint range")
+		}
+		if node.Type != "" {
+			if err := checkRange(start, last, pos); err != nil {
+				return types.Unknown, nil, err
+			}
+			last--
+		}
+		span = last - start
+		cur, done := start, start > last
+		next = func() (any, bool) {
+			if done {
+				return nil, false
+			}
+			v := cur
+			if cur == last {
+				done = true
+			} else {
+				cur++
+			}
+			return v, true
+		}
+	}
+
+	if node.Type == "" {
+		return varType, next, nil
+	}
+
+	// The range has span + 1 values
+	if span >= MaxShuffledRangeSize {
+		return types.Unknown, nil, NewInvalidRangeError(pos, "range is too large to iterate in random order")
+	}
+
+	values := make([]any, 0, span+1)
+	for v, ok := next(); ok; v, ok = next() {
+		values = append(values, v)
+	}
+	i.Rand.Shuffle(len(values), func(a, b int) {
+		values[a], values[b] = values[b], values[a]
+	})
+
+	idx := 0
+	return varType, func() (any, bool) {
+		if idx >= len(values) {
+			return nil, false
+		}
+		idx++
+		return values[idx-1], true
+	}, nil
+}
+
+// isNegative reports whether v is a number below zero
+func isNegative(v any) bool {
+	switch val := v.(type) {
+	case int64:
+		return val < 0
+	case float64:
+		return val < 0
+	}
+	return false
 }
 
 func toInt64(v any) (int64, bool) {
@@ -89,12 +315,27 @@ func toInt64(v any) (int64, bool) {
 	return 0, false
 }
 
+func toUint64(v any) (uint64, bool) {
+	switch val := v.(type) {
+	case int64:
+		if val < 0 {
+			return 0, false
+		}
+		return uint64(val), true
+	case uint64:
+		return val, true
+	}
+	return 0, false
+}
+
 func toFloat64(v any) (float64, bool) {
 	switch val := v.(type) {
 	case int:
 		return float64(val), true
 	case int64:
 		return float64(val), true
+	case uint64:
+		return float64(val), true
 	case float64:
 		return val, true
 	case types.UnofloatType:
@@ -103,7 +344,7 @@ func toFloat64(v any) (float64, bool) {
 	return 0, false
 }
 
-func checkRange[T int64 | float64](min, max T, pos *Position) error {
+func checkRange[T int64 | uint64 | float64](min, max T, pos *Position) error {
 	if min > max {
 		return NewInvalidRangeError(pos, "min is greater than max")
 	}
@@ -114,7 +355,7 @@ func checkRange[T int64 | float64](min, max T, pos *Position) error {
 }
 
 func getTypeString(value any) string {
-	switch value.(type) {
+	switch v := value.(type) {
 	case int64:
 		return "int"
 	case uint64:
@@ -127,17 +368,70 @@ func getTypeString(value any) string {
 		return "bool"
 	case string:
 		return "string"
+	case *Function:
+		return "func"
+	case *types.ArrayValue:
+		return v.ElemType.String() + "[]"
+	case *types.MapValue:
+		return v.TypeString()
+	case *types.StructValue:
+		return v.TypeName
+	case types.EnumValue:
+		return v.TypeName
+	case *Module:
+		return "module"
 	default:
 		return "unknown"
 	}
 }
 
+// valueType returns the type of a runtime value, used to infer the element type of array literals
+func valueType(value any) types.VarType {
+	switch value.(type) {
+	case int64:
+		return types.Int
+	case uint64:
+		return types.Uint
+	case float64:
+		return types.Float
+	case types.UnofloatType:
+		return types.Unofloat
+	case bool:
+		return types.Bool
+	case string:
+		return types.String
+	case *Function:
+		return types.Func
+	case *types.ArrayValue:
+		return types.Array
+	case *types.MapValue:
+		return types.Map
+	case *types.StructValue:
+		return types.Struct
+	case types.EnumValue:
+		return types.Enum
+	case *Module:
+		return types.Module
+	default:
+		return types.Unknown
+	}
+}
+
 func defaultTypeCompatibility(expectedType *types.VarType, value any, pos *Position) error {
 	switch value.(type) {
 	case int64, uint64, float64, types.UnofloatType:
 		return nil
 	}
-	return NewRuntimeError(pos, "type mistmatch: expected %s, got %s", expectedType.String(), getTypeString(value))
+	return NewTypeMismatchErrorf(pos, "expected %s, got %s", expectedType.String(), getTypeString(value))
+}
+
+// isKeyType reports whether values of type t can be used as map keys
+func isKeyType(t types.VarType) bool {
+	switch t {
+	case types.Int, types.Uint, types.Float, types.Unofloat, types.Bool, types.String:
+		return true
+	}
+	return false
 }
 
 func (i *Interpreter) checkTypeCompatibility(expectedType types.VarType, value any, pos *Position) error {
@@ -152,11 +446,31 @@ func (i *Interpreter) checkTypeCompatibility(expectedType types.VarType, value a
 		return defaultTypeCompatibility(&expectedType, value, pos)
 	case types.Bool:
 		if _, ok := value.(bool); !ok {
-			return NewRuntimeError(pos, "type mismatch: expected bool, got %T", value)
+			return NewTypeMismatchErrorf(pos, "expected bool, got %T", value)
 		}
 	case types.String:
 		if _, ok := value.(string); !ok {
-			return NewRuntimeError(pos, "type mismatch: expected string, got %T", value)
+			return NewTypeMismatchErrorf(pos, "expected string, got %T", value)
+		}
+	case types.Func:
+		if _, ok := value.(*Function); !ok {
+			return NewTypeMismatchErrorf(pos, "expected func, got %s", getTypeString(value))
+		}
+	case types.Array:
+		if _, ok := value.(*types.ArrayValue); !ok {
+			return NewTypeMismatchErrorf(pos, "expected array, got %s", getTypeString(value))
+		}
+	case types.Map:
+		if _, ok := value.(*types.MapValue); !ok {
+			return NewTypeMismatchErrorf(pos, "expected map, got %s", getTypeString(value))
+		}
+	case types.Struct:
+		if _, ok := value.(*types.StructValue); !ok {
+			return NewTypeMismatchErrorf(pos, "expected struct, got %s", getTypeString(value))
+		}
+	case types.Enum:
+		if _, ok := value.(types.EnumValue); !ok {
+			return NewTypeMismatchErrorf(pos, "expected enum, got %s", getTypeString(value))
 		}
 	}
 	return nil
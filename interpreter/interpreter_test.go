@@ -1,7 +1,12 @@
 package interpreter
 
 import (
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
+	"wtf-script/config"
 	"wtf-script/types"
 )
 
@@ -286,6 +291,92 @@ func TestInterpreter_ShortCircuitEvaluation(t *testing.T) {
 	}
 }
 
+func TestInterpreter_ConditionalExpression(t *testing.T) {
+	input := `
+	int x = 5;
+	string size = x > 3 ? "big" : "small";
+	int sign = x < 0 ? -1 : x == 0 ? 0 : 1;
+	bool either = false || true ? true : false;
+	float half = x > 0 ? 0.5 * x : 0.0;
+	`
+	i := NewInterpreter(nil)
+	i.Execute(input)
+
+	expected := map[string]any{"size": "big", "sign": int64(1), "either": true, "half": 2.5}
+	for name, want := range expected {
+		if got := i.Variables[name].Value; got != want {
+			t.Errorf("%s: expected %v, got %v", name, want, got)
+		}
+	}
+}
+
+func TestInterpreter_ConditionalShortCircuit(t *testing.T) {
+	// Only the selected branch is evaluated, so neither division by zero runs
+	input := `
+	int calls = 0;
+	func tick() int {
+		calls = calls + 1;
+		return calls;
+	}
+	int a = true ? tick() : 1 / 0;
+	int b = false ? 1 / 0 : tick();
+	int c = ifrand(1.0) ? tick() : 1 / 0;
+	int d = ifrand(0.0) ? 1 / 0 : tick();
+	`
+	i := NewInterpreter(nil)
+	i.Execute(input)
+
+	if calls := i.Variables["calls"].Value; calls != int64(4) {
+		t.Errorf("expected 4 calls, got %v", calls)
+	}
+	if d := i.Variables["d"].Value; d != int64(4) {
+		t.Errorf("expected d to be 4, got %v", d)
+	}
+}
+
+func TestInterpreter_IfrandExpressionDistribution(t *testing.T) {
+	input := `
+	seed(42);
+	int hits = 0;
+	repeat 10000 {
+		hits = hits + (ifrand(0.3) ? 1 : 0);
+	}
+	`
+	i := NewInterpreter(nil)
+	i.Execute(input)
+
+	hits := i.Variables["hits"].Value.(int64)
+	if hits < 2700 || hits > 3300 {
+		t.Errorf("expected about 3000 hits, got %d", hits)
+	}
+}
+
+func TestInterpreter_ConditionalErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+	}{
+		{"non_bool_condition", "int x = 1 ? 2 : 3;"},
+		{"invalid_probability", "int x = ifrand(1.5) ? 2 : 3;"},
+		{"string_probability", `int x = ifrand("a") ? 2 : 3;`},
+		{"error_in_branch", "int x = true ? 1 / 0 : 3;"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := NewLexer("test", tt.input)
+			p := NewParser(l)
+			program := p.ParseProgram()
+			checkParserErrors(t, p)
+
+			i := NewInterpreter(nil)
+			if _, err := i.Evaluate(program); err == nil {
+				t.Error("expected runtime error, got none")
+			}
+		})
+	}
+}
+
 // ============================================================================
 // If/Else Statement Tests
 // ============================================================================
@@ -447,219 +538,3452 @@ func TestInterpreter_IfrandDeterministic(t *testing.T) {
 }
 
 // ============================================================================
-// Uint Type Tests
+// While Loop Tests
 // ============================================================================
 
-func TestInterpreter_UintBasic(t *testing.T) {
-	input := "uint x = 100;"
+func TestInterpreter_WhileLoop(t *testing.T) {
+	input := `
+	int i = 0;
+	int sum = 0;
+	while (i < 5) {
+		i = i + 1;
+		sum = sum + i;
+	}
+	`
 	i := NewInterpreter(nil)
 	i.Execute(input)
 
-	v, ok := i.Variables["x"]
+	v, ok := i.Variables["sum"]
 	if !ok {
-		t.Fatal("variable 'x' not found")
+		t.Fatal("variable 'sum' not found")
+	}
+	if val, ok := v.Value.(int64); !ok || val != 15 {
+		t.Errorf("expected 15, got %v", v.Value)
 	}
+}
 
-	if v.Type != types.Uint {
-		t.Errorf("expected type Uint, got %v", v.Type)
+func TestInterpreter_WhileBreakContinue(t *testing.T) {
+	input := `
+	int i = 0;
+	int odd = 0;
+	while (true) {
+		i = i + 1;
+		if (i > 10) {
+			break;
+		}
+		if (i / 2 * 2 == i) {
+			continue;
+		}
+		odd = odd + 1;
 	}
+	`
+	i := NewInterpreter(nil)
+	i.Execute(input)
 
-	val, ok := v.Value.(uint64)
-	if !ok || val != 100 {
-		t.Errorf("expected 100, got %v", v.Value)
+	v := i.Variables["odd"]
+	if val, ok := v.Value.(int64); !ok || val != 5 {
+		t.Errorf("expected 5, got %v", v.Value)
 	}
-}
 
-func TestInterpreter_UintRange(t *testing.T) {
-	t.Skip("uint type doesn't support range syntax - skipping test")
+	v = i.Variables["i"]
+	if val, ok := v.Value.(int64); !ok || val != 11 {
+		t.Errorf("expected 11, got %v", v.Value)
+	}
 }
 
-func TestInterpreter_UintArithmetic(t *testing.T) {
+func TestInterpreter_NestedWhileBreak(t *testing.T) {
+	// break only leaves the innermost loop
 	input := `
-	uint x = 10;
-	uint y = 5;
-	uint result = x + y;
+	int outer = 0;
+	int total = 0;
+	while (outer < 3) {
+		outer = outer + 1;
+		int inner = 0;
+		while (true) {
+			inner = inner + 1;
+			total = total + 1;
+			if (inner == 2) {
+				break;
+			}
+		}
+	}
 	`
 	i := NewInterpreter(nil)
 	i.Execute(input)
 
-	v, ok := i.Variables["result"]
-	if !ok {
-		t.Fatal("variable 'result' not found")
+	v := i.Variables["total"]
+	if val, ok := v.Value.(int64); !ok || val != 6 {
+		t.Errorf("expected 6, got %v", v.Value)
 	}
+}
 
-	val, ok := v.Value.(uint64)
-	if !ok || val != 15 {
-		t.Errorf("expected 15, got %v", v.Value)
+func TestInterpreter_WhileConditionMustBeBool(t *testing.T) {
+	l := NewLexer("test", "while (1) { }")
+	p := NewParser(l)
+	program := p.ParseProgram()
+
+	i := NewInterpreter(nil)
+	if _, err := i.Evaluate(program); err == nil {
+		t.Error("expected error for non-bool while condition")
+	}
+}
+
+func TestInterpreter_BreakOutsideLoop(t *testing.T) {
+	l := NewLexer("test", "break;")
+	p := NewParser(l)
+	program := p.ParseProgram()
+
+	i := NewInterpreter(nil)
+	if _, err := i.Evaluate(program); err == nil {
+		t.Error("expected error for break outside of loop")
 	}
 }
 
 // ============================================================================
-// Unofloat Type Tests
+// For Loop Tests
 // ============================================================================
 
-func TestInterpreter_UnofloatBasic(t *testing.T) {
-	input := "unofloat x = 0.5;"
+func TestInterpreter_ForLoop(t *testing.T) {
+	input := `
+	int sum = 0;
+	for (int i = 1; i <= 4; i = i + 1) {
+		sum = sum + i;
+	}
+	`
 	i := NewInterpreter(nil)
 	i.Execute(input)
 
-	v, ok := i.Variables["x"]
-	if !ok {
-		t.Fatal("variable 'x' not found")
+	v := i.Variables["sum"]
+	if val, ok := v.Value.(int64); !ok || val != 10 {
+		t.Errorf("expected 10, got %v", v.Value)
 	}
+}
 
-	if v.Type != types.Unofloat {
-		t.Errorf("expected type UnitFloat, got %v", v.Type)
+func TestInterpreter_ForLoopContinueRunsPost(t *testing.T) {
+	input := `
+	int count = 0;
+	for (int i = 0; i < 10; i = i + 1) {
+		if (i < 5) {
+			continue;
+		}
+		count = count + 1;
 	}
+	`
+	i := NewInterpreter(nil)
+	i.Execute(input)
 
-	val, ok := v.Value.(types.UnofloatType)
-	if !ok {
-		t.Fatalf("value is not Unofloat: %T", v.Value)
+	v := i.Variables["count"]
+	if val, ok := v.Value.(int64); !ok || val != 5 {
+		t.Errorf("expected 5, got %v", v.Value)
 	}
+}
 
-	if float64(val) != 0.5 {
-		t.Errorf("expected 0.5, got %v", float64(val))
+func TestInterpreter_ForInRange(t *testing.T) {
+	input := `
+	int sum = 0;
+	int n = 4;
+	for i in 0..n {
+		sum = sum + i;
+	}
+	`
+	i := NewInterpreter(nil)
+	i.Execute(input)
+
+	v := i.Variables["sum"]
+	if val, ok := v.Value.(int64); !ok || val != 10 {
+		t.Errorf("expected 10 (range is inclusive), got %v", v.Value)
 	}
 }
 
-func TestInterpreter_UnofloatRange(t *testing.T) {
-	t.Skip("unofloat type doesn't support range syntax - skipping test")
+func TestInterpreter_ForInEmptyRange(t *testing.T) {
+	input := `
+	int count = 0;
+	for i in 5..1 {
+		count = count + 1;
+	}
+	`
+	i := NewInterpreter(nil)
+	i.Execute(input)
+
+	v := i.Variables["count"]
+	if val, ok := v.Value.(int64); !ok || val != 0 {
+		t.Errorf("expected 0, got %v", v.Value)
+	}
 }
 
-func TestInterpreter_UnofloatClamping(t *testing.T) {
-	// Test that unofloat clamps values to [0, 1] for computed expressions
+func TestInterpreter_ForInFullRange(t *testing.T) {
+	// The number of values does not fit a uint64, the loop still starts at the min
 	input := `
-	unofloat x = 0.8;
-	unofloat y = 0.5;
-	unofloat result = x + y;
+	int first = 0;
+	int count = 0;
+	for i in -9223372036854775808..9223372036854775807 {
+		first = i;
+		count = count + 1;
+		break;
+	}
+	uint last = 0u;
+	for u in 18446744073709551615u..18446744073709551615u {
+		last = u;
+	}
 	`
 	i := NewInterpreter(nil)
 	i.Execute(input)
 
-	v, ok := i.Variables["result"]
-	if !ok {
-		t.Fatal("variable 'result' not found")
+	if first := i.Variables["first"].Value; first != int64(math.MinInt64) {
+		t.Errorf("expected %d, got %v", int64(math.MinInt64), first)
+	}
+	if count := i.Variables["count"].Value; count != int64(1) {
+		t.Errorf("expected one iteration, got %v", count)
 	}
+	if last := i.Variables["last"].Value; last != uint64(math.MaxUint64) {
+		t.Errorf("expected %d, got %v", uint64(math.MaxUint64), last)
+	}
+}
 
-	val, ok := v.Value.(types.UnofloatType)
-	if !ok {
-		t.Fatalf("value is not Unofloat: %v", v.Value)
+func TestInterpreter_ForInRandomRange(t *testing.T) {
+	// A typed range visits every value exactly once, in random order
+	input := `
+	int sum = 0;
+	int count = 0;
+	for i in int(1, 7) {
+		sum = sum + i;
+		count = count + 1;
 	}
+	`
+	i := NewInterpreter(nil)
+	i.Execute(input)
 
-	fval := float64(val)
-	// 0.8 + 0.5 = 1.3, should be clamped to 1.0
-	if fval != 1.0 {
-		t.Errorf("expected 1.0 (clamped), got %v", fval)
+	sum := i.Variables["sum"]
+	if val, ok := sum.Value.(int64); !ok || val != 21 {
+		t.Errorf("expected sum 21, got %v", sum.Value)
+	}
+	count := i.Variables["count"]
+	if val, ok := count.Value.(int64); !ok || val != 6 {
+		t.Errorf("expected count 6, got %v", count.Value)
 	}
 }
 
-func TestInterpreter_UnofloatArithmetic(t *testing.T) {
+func TestInterpreter_ForInUintRange(t *testing.T) {
 	input := `
-	unofloat x = 0.6;
-	unofloat y = 0.2;
-	unofloat sum = x + y;
-	unofloat diff = x - y;
-	unofloat prod = x * y;
-	unofloat quot = x / y;
+	string kind = "";
+	for i in uint(1, 3) {
+		kind = typeof(i);
+	}
 	`
 	i := NewInterpreter(nil)
 	i.Execute(input)
 
+	v := i.Variables["kind"]
+	if val, ok := v.Value.(string); !ok || val != "uint" {
+		t.Errorf("expected loop variable of type uint, got %v", v.Value)
+	}
+}
+
+func TestInterpreter_ForInInvalidRange(t *testing.T) {
+	// Typed ranges follow the rules of ranged declarations
 	tests := []struct {
-		name     string
-		expected float64
+		input string
+		msg   string
 	}{
-		{"sum", 0.8},
-		{"diff", 0.4},
-		{"prod", 0.12},
-		{"quot", 1.0}, // 0.6 / 0.2 = 3.0, clamped to 1.0
+		{"for i in int(6, 1) { }", "min is greater than max"},
+		{"for i in uint(5, 5) { }", "min is equal to max"},
+		{"for i in int(0, 2000000) { }", "range is too large to iterate in random order"},
 	}
 
 	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			v, ok := i.Variables[tt.name]
+		t.Run(tt.input, func(t *testing.T) {
+			l := NewLexer("test", tt.input)
+			p := NewParser(l)
+			program := p.ParseProgram()
+			checkParserErrors(t, p)
+
+			i := NewInterpreter(nil)
+			_, err := i.Evaluate(program)
+			rErr, ok := err.(*RuntimeError)
 			if !ok {
-				t.Fatalf("variable '%s' not found", tt.name)
+				t.Fatalf("expected a runtime error, got %v", err)
 			}
+			if rErr.Kind != ErrorKindInvalidRange || rErr.Msg != tt.msg {
+				t.Errorf("expected invalid_range %q, got %s %q", tt.msg, rErr.Kind, rErr.Msg)
+			}
+		})
+	}
+}
 
-			val, ok := v.Value.(types.UnofloatType)
+func TestInterpreter_ForInNonIntegerBounds(t *testing.T) {
+	tests := []struct {
+		input string
+		msg   string
+	}{
+		{"for i in 1..5.5 { }", "type mismatch: range bounds must be int or uint, got float"},
+		{"for i in 1.5..5 { }", "type mismatch: range bounds must be int or uint, got float"},
+		{"for i in int(1, 5.5) { }", "type mismatch: range bounds must be int or uint, got float"},
+		{`for i in 1.."5" { }`, "type mismatch: range bounds must be int or uint, got string"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			l := NewLexer("test", tt.input)
+			p := NewParser(l)
+			program := p.ParseProgram()
+			checkParserErrors(t, p)
+
+			i := NewInterpreter(nil)
+			_, err := i.Evaluate(program)
+			rErr, ok := err.(*RuntimeError)
 			if !ok {
-				t.Fatalf("value is not Unofloat: %T", v.Value)
+				t.Fatalf("expected a runtime error, got %v", err)
 			}
-
-			fval := float64(val)
-			// Allow small floating point differences
-			if fval < tt.expected-0.01 || fval > tt.expected+0.01 {
-				t.Errorf("expected ~%v, got %v", tt.expected, fval)
+			if rErr.Kind != ErrorKindTypeMismatch || rErr.Msg != tt.msg {
+				t.Errorf("expected type_mismatch %q, got %s %q", tt.msg, rErr.Kind, rErr.Msg)
 			}
 		})
 	}
 }
 
 // ============================================================================
-// String Operation Tests
+// Random Loop Tests
 // ============================================================================
 
-func TestInterpreter_StringConcatenation(t *testing.T) {
+func TestInterpreter_Repeat(t *testing.T) {
 	input := `
-	string s1 = "Hello";
-	string s2 = " World";
-	string result = s1 + s2;
+	int count = 0;
+	int n = 4;
+	repeat n + 1 {
+		count = count + 1;
+	}
 	`
 	i := NewInterpreter(nil)
 	i.Execute(input)
 
-	v, ok := i.Variables["result"]
-	if !ok {
-		t.Fatal("variable 'result' not found")
+	v := i.Variables["count"]
+	if val, ok := v.Value.(int64); !ok || val != 5 {
+		t.Errorf("expected 5, got %v", v.Value)
 	}
+}
 
-	val, ok := v.Value.(string)
-	if !ok || val != "Hello World" {
-		t.Errorf("expected 'Hello World', got %v", v.Value)
+func TestInterpreter_RepeatNegativeCount(t *testing.T) {
+	l := NewLexer("test", "repeat -1 { }")
+	p := NewParser(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	i := NewInterpreter(nil)
+	if _, err := i.Evaluate(program); err == nil {
+		t.Error("expected error for negative repeat count")
 	}
 }
 
-func TestInterpreter_StringComparison(t *testing.T) {
+func TestInterpreter_Repeatrand(t *testing.T) {
 	input := `
-	string s1 = "apple";
-	string s2 = "banana";
-	bool eq = s1 == s2;
-	bool neq = s1 != s2;
-	bool lt = s1 < s2;
+	int count = 0;
+	repeatrand(2, 5) {
+		count = count + 1;
+	}
 	`
-	i := NewInterpreter(nil)
-	i.Execute(input)
+	seen := map[int64]bool{}
+	for n := 0; n < 100; n++ {
+		i := NewInterpreter(nil)
+		i.Execute(input)
+
+		v := i.Variables["count"]
+		val, ok := v.Value.(int64)
+		if !ok || val < 2 || val > 4 {
+			t.Fatalf("count %v out of range [2, 5)", v.Value)
+		}
+		seen[val] = true
+	}
 
-	eqVal, _ := i.Variables["eq"]
-	if val, ok := eqVal.Value.(bool); !ok || val != false {
-		t.Errorf("expected eq=false, got %v", eqVal.Value)
+	// Like int(min, max), max is excluded, so the counts are 2, 3 and 4
+	if len(seen) != 3 {
+		t.Errorf("expected counts 2, 3 and 4, got %v", seen)
 	}
+}
 
-	neqVal, _ := i.Variables["neq"]
-	if val, ok := neqVal.Value.(bool); !ok || val != true {
-		t.Errorf("expected neq=true, got %v", neqVal.Value)
+func TestInterpreter_RepeatrandBounds(t *testing.T) {
+	// A span of one leaves min as the only count
+	for n := 0; n < 20; n++ {
+		i := NewInterpreter(nil)
+		i.Execute("int count = 0; repeatrand(2, 3) { count = count + 1; }")
+		if count := i.Variables["count"].Value; count != int64(2) {
+			t.Fatalf("expected 2 iterations, got %v", count)
+		}
 	}
 
-	ltVal, _ := i.Variables["lt"]
-	if val, ok := ltVal.Value.(bool); !ok || val != true {
-		t.Errorf("expected lt=true, got %v", ltVal.Value)
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"repeatrand(3, 3) { }", "min is equal to max"},
+		{"repeatrand(4, 3) { }", "min is greater than max"},
 	}
-}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			l := NewLexer("test", tt.input)
+			p := NewParser(l)
+			program := p.ParseProgram()
+			checkParserErrors(t, p)
 
-// ============================================================================
-// Builtin Function Tests
-// ============================================================================
+			i := NewInterpreter(nil)
+			_, err := i.Evaluate(program)
+			rtErr, ok := err.(*RuntimeError)
+			if !ok {
+				t.Fatalf("expected RuntimeError, got %v", err)
+			}
+			if rtErr.Kind != ErrorKindInvalidRange || rtErr.Msg != tt.expected {
+				t.Errorf("expected %s error %q, got %s error %q", ErrorKindInvalidRange, tt.expected, rtErr.Kind, rtErr.Msg)
+			}
+		})
+	}
+}
 
-func TestInterpreter_SeedFunction(t *testing.T) {
+func TestInterpreter_RepeatrandWideRange(t *testing.T) {
 	input := `
-	seed(42);
-	int(1, 100) x;
+	int count = 0;
+	repeatrand(0, 9223372036854775807) {
+		count = count + 1;
+		break;
+	}
 	`
+	// The span of the bounds does not fit an int64, count is 0 only if 0 was drawn
+	for n := 0; n < 10; n++ {
+		i := NewInterpreter(nil)
+		i.Execute(input)
 
-	i1 := NewInterpreter(nil)
-	i1.Execute(input)
-	v1 := i1.Variables["x"]
+		if count := i.Variables["count"].Value; count != int64(1) && count != int64(0) {
+			t.Fatalf("expected the loop to run at most once, got %v", count)
+		}
+	}
+}
+
+func TestInterpreter_Whilerand(t *testing.T) {
+	// Probability 0 never runs the body
+	i := NewInterpreter(nil)
+	i.Execute(`
+	int count = 0;
+	whilerand(0.0) {
+		count = count + 1;
+	}
+	`)
+	v := i.Variables["count"]
+	if val, ok := v.Value.(int64); !ok || val != 0 {
+		t.Errorf("expected 0, got %v", v.Value)
+	}
+
+	// Probability 1 runs until break
+	i = NewInterpreter(nil)
+	i.Execute(`
+	int count = 0;
+	whilerand(1.0) {
+		count = count + 1;
+		if (count == 7) {
+			break;
+		}
+	}
+	`)
+	v = i.Variables["count"]
+	if val, ok := v.Value.(int64); !ok || val != 7 {
+		t.Errorf("expected 7, got %v", v.Value)
+	}
+}
+
+func TestInterpreter_WhilerandInvalidProbability(t *testing.T) {
+	l := NewLexer("test", "whilerand(1.5) { }")
+	p := NewParser(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	i := NewInterpreter(nil)
+	if _, err := i.Evaluate(program); err == nil {
+		t.Error("expected error for whilerand probability out of range")
+	}
+}
+
+// ============================================================================
+// Function Tests
+// ============================================================================
+
+func TestInterpreter_FunctionCall(t *testing.T) {
+	input := `
+	func add(int a, int b) int {
+		return a + b;
+	}
+	int result = add(2, 3);
+	`
+	i := NewInterpreter(nil)
+	i.Execute(input)
+
+	v, ok := i.Variables["result"]
+	if !ok {
+		t.Fatal("variable 'result' not found")
+	}
+	if val, ok := v.Value.(int64); !ok || val != 5 {
+		t.Errorf("expected 5, got %v", v.Value)
+	}
+
+	// Parameters live in the call frame, not the global scope
+	if _, ok := i.Variables["a"]; ok {
+		t.Error("parameter 'a' leaked into the global scope")
+	}
+}
+
+func TestInterpreter_FunctionRecursion(t *testing.T) {
+	input := `
+	func fact(int n) int {
+		if (n <= 1) {
+			return 1;
+		}
+		return n * fact(n - 1);
+	}
+	int result = fact(5);
+	`
+	i := NewInterpreter(nil)
+	i.Execute(input)
+
+	v := i.Variables["result"]
+	if val, ok := v.Value.(int64); !ok || val != 120 {
+		t.Errorf("expected 120, got %v", v.Value)
+	}
+}
+
+func TestInterpreter_FunctionReadsGlobals(t *testing.T) {
+	input := `
+	int counter = 0;
+	func bump() {
+		counter = counter + 1;
+	}
+	bump();
+	bump();
+	`
+	i := NewInterpreter(nil)
+	i.Execute(input)
+
+	v := i.Variables["counter"]
+	if val, ok := v.Value.(int64); !ok || val != 2 {
+		t.Errorf("expected 2, got %v", v.Value)
+	}
+}
+
+func TestInterpreter_FunctionReturnTypeCast(t *testing.T) {
+	input := `
+	func half(int n) float {
+		return n / 2;
+	}
+	float result = half(5);
+	`
+	i := NewInterpreter(nil)
+	i.Execute(input)
+
+	v := i.Variables["result"]
+	if val, ok := v.Value.(float64); !ok || val != 2.0 {
+		t.Errorf("expected 2.0 (float), got %v (%T)", v.Value, v.Value)
+	}
+}
+
+func TestInterpreter_FunctionRandomDefaultArgument(t *testing.T) {
+	input := `
+	func roll(int(1, 7) face) int {
+		return face;
+	}
+	int result = roll();
+	int fixed = roll(3);
+	`
+	for n := 0; n < 20; n++ {
+		i := NewInterpreter(nil)
+		i.Execute(input)
+
+		v := i.Variables["result"]
+		val, ok := v.Value.(int64)
+		if !ok || val < 1 || val > 6 {
+			t.Fatalf("random default %v out of range [1, 6]", v.Value)
+		}
+
+		f := i.Variables["fixed"]
+		if val, ok := f.Value.(int64); !ok || val != 3 {
+			t.Fatalf("expected 3, got %v", f.Value)
+		}
+	}
+}
+
+func TestInterpreter_FunctionErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+	}{
+		{"missing_argument", "func f(int x) int { return x; } f();"},
+		{"too_many_arguments", "func f(int x) int { return x; } f(1, 2);"},
+		{"argument_type_mismatch", `func f(int x) int { return x; } f("one");`},
+		{"negative_uint_argument", "func f(uint x) uint { return x; } f(-1);"},
+		{"missing_return_value", "func f() int { } f();"},
+		{"return_type_mismatch", `func f() int { return "x"; } f();`},
+		{"value_from_void_function", "func f() { return 1; } f();"},
+		{"return_outside_function", "return 1;"},
+		{"break_inside_function", "while (true) { func f() { break; } f(); }"},
+		{"redeclared_function", "func f() { } func f() { }"},
+		{"redeclared_builtin", "func print() { }"},
+		{"unbounded_recursion", "func f() { f(); } f();"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := NewLexer("test", tt.input)
+			p := NewParser(l)
+			program := p.ParseProgram()
+			checkParserErrors(t, p)
+
+			i := NewInterpreter(nil)
+			if _, err := i.Evaluate(program); err == nil {
+				t.Error("expected runtime error, got none")
+			}
+		})
+	}
+}
+
+func TestInterpreter_FunctionValues(t *testing.T) {
+	input := `
+	func double = func(int x) int { return x * 2; };
+	func apply(func f, int x) int {
+		return f(x);
+	}
+	int a = double(4);
+	int b = apply(double, 5);
+	int c = apply(func(int x) int { return x + 1; }, 5);
+	int d = func(int x) int { return x * x; }(3);
+	string kind = typeof(double);
+	`
+	i := NewInterpreter(nil)
+	i.Execute(input)
+
+	expected := map[string]int64{"a": 8, "b": 10, "c": 6, "d": 9}
+	for name, want := range expected {
+		v, ok := i.Variables[name]
+		if !ok {
+			t.Fatalf("variable '%s' not found", name)
+		}
+		if val, ok := v.Value.(int64); !ok || val != want {
+			t.Errorf("%s: expected %d, got %v", name, want, v.Value)
+		}
+	}
+
+	if v := i.Variables["kind"]; v.Value != "func" {
+		t.Errorf("expected typeof to report func, got %v", v.Value)
+	}
+}
+
+func TestInterpreter_Closures(t *testing.T) {
+	input := `
+	func makeCounter() func {
+		int count = 0;
+		return func() int {
+			count = count + 1;
+			return count;
+		};
+	}
+	func makeAdder(int n) func {
+		return func(int x) int { return x + n; };
+	}
+
+	func next = makeCounter();
+	func other = makeCounter();
+	next();
+	next();
+	int c = next();
+	int o = other();
+	int sum = makeAdder(10)(5);
+	`
+	i := NewInterpreter(nil)
+	i.Execute(input)
+
+	expected := map[string]int64{"c": 3, "o": 1, "sum": 15}
+	for name, want := range expected {
+		v := i.Variables[name]
+		if val, ok := v.Value.(int64); !ok || val != want {
+			t.Errorf("%s: expected %d, got %v", name, want, v.Value)
+		}
+	}
+}
+
+func TestInterpreter_RandomStrategy(t *testing.T) {
+	// A strategy function chosen with ifrand
+	input := `
+	func cautious = func(int x) int { return x; };
+	func greedy = func(int x) int { return x * 10; };
+	func strategy = cautious;
+	ifrand(1.0) {
+		strategy = greedy;
+	}
+	int result = strategy(3);
+	`
+	i := NewInterpreter(nil)
+	i.Execute(input)
+
+	v := i.Variables["result"]
+	if val, ok := v.Value.(int64); !ok || val != 30 {
+		t.Errorf("expected 30, got %v", v.Value)
+	}
+}
+
+func TestInterpreter_CallBuiltin(t *testing.T) {
+	input := `
+	func add(int a, int b) int { return a + b; }
+	int result = call(add, 2, 3);
+	`
+	i := NewInterpreter(nil)
+	i.Execute(input)
+
+	v := i.Variables["result"]
+	if val, ok := v.Value.(int64); !ok || val != 5 {
+		t.Errorf("expected 5, got %v", v.Value)
+	}
+}
+
+func TestInterpreter_CallBuiltinPropagatesErrors(t *testing.T) {
+	input := `
+	string kind = "";
+	try {
+		call(func() int { return 1 / 0; });
+	} catch (err) {
+		kind = err.kind;
+	}
+	`
+	i := NewInterpreter(nil)
+	i.Execute(input)
+
+	if kind := i.Variables["kind"].Value; kind != "division_by_zero" {
+		t.Errorf("expected the error to be caught, got kind %v", kind)
+	}
+
+	input = `
+	bool after = false;
+	func f() { assert(false, "inner"); }
+	call(f);
+	after = true;
+	`
+	l := NewLexer("test", input)
+	p := NewParser(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	i = NewInterpreter(nil)
+	_, err := i.Evaluate(program)
+	if _, ok := err.(*AssertionError); !ok {
+		t.Fatalf("expected an assertion error, got %v", err)
+	}
+	if after := i.Variables["after"].Value; after != false {
+		t.Error("expected the failed assert to stop the script")
+	}
+}
+
+func TestInterpreter_FunctionValueErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+	}{
+		{"uninitialized_func", "func f;"},
+		{"int_to_func", "func f = 5;"},
+		{"func_to_int", "int x = func() { };"},
+		{"call_non_function", "int x = 5; x();"},
+		{"call_literal_result", "func f = func() int { return 1; }; f()();"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := NewLexer("test", tt.input)
+			p := NewParser(l)
+			program := p.ParseProgram()
+			checkParserErrors(t, p)
+
+			i := NewInterpreter(nil)
+			if _, err := i.Evaluate(program); err == nil {
+				t.Error("expected runtime error, got none")
+			}
+		})
+	}
+}
+
+// ============================================================================
+// Array Tests
+// ============================================================================
+
+func TestInterpreter_ArrayRandomFill(t *testing.T) {
+	input := `
+	int[5] rolls;
+	int(1, 7)[100] dice;
+	string[] empty;
+	`
+	i := NewInterpreter(nil)
+	i.Execute(input)
+
+	rolls, ok := i.Variables["rolls"].Value.(*types.ArrayValue)
+	if !ok {
+		t.Fatalf("expected array, got %T", i.Variables["rolls"].Value)
+	}
+	if i.Variables["rolls"].Type != types.Array || rolls.ElemType != types.Int {
+		t.Errorf("expected int array, got %s of %s", i.Variables["rolls"].Type, rolls.ElemType)
+	}
+	if len(rolls.Elements) != 5 {
+		t.Fatalf("expected 5 elements, got %d", len(rolls.Elements))
+	}
+	for idx, el := range rolls.Elements {
+		val, ok := el.(int64)
+		if !ok || val < -1000 || val > 1000 {
+			t.Errorf("rolls[%d]: expected int in default range, got %v", idx, el)
+		}
+	}
+
+	dice := i.Variables["dice"].Value.(*types.ArrayValue)
+	if len(dice.Elements) != 100 {
+		t.Fatalf("expected 100 elements, got %d", len(dice.Elements))
+	}
+	for idx, el := range dice.Elements {
+		if val := el.(int64); val < 1 || val >= 7 {
+			t.Errorf("dice[%d]: expected value in [1, 7), got %d", idx, val)
+		}
+	}
+
+	if empty := i.Variables["empty"].Value.(*types.ArrayValue); len(empty.Elements) != 0 {
+		t.Errorf("expected empty array, got %v", empty)
+	}
+}
+
+func TestInterpreter_ArrayLiterals(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+		elemType types.VarType
+	}{
+		{"int", "int[] xs = [1, 2, 3];", "[1, 2, 3]", types.Int},
+		{"float_keeps_fraction", "float[] xs = [1, 2.5];", "[1.000000, 2.500000]", types.Float},
+		{"sized", "int[2] xs = [4, 2];", "[4, 2]", types.Int},
+		{"fcfs_inference", "int[] xs = [1, 2.7];", "[1, 2]", types.Int},
+		{"converted_array", "float[] xs = [1, 2];", "[1.000000, 2.000000]", types.Float},
+		{"copy_from_variable", "int[] a = [1, 2]; uint[] xs = a;", "[1, 2]", types.Uint},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			i := NewInterpreter(nil)
+			i.Execute(tt.input)
+
+			xs, ok := i.Variables["xs"].Value.(*types.ArrayValue)
+			if !ok {
+				t.Fatalf("expected array, got %T", i.Variables["xs"].Value)
+			}
+			if xs.String() != tt.expected {
+				t.Errorf("expected %s, got %s", tt.expected, xs.String())
+			}
+			if xs.ElemType != tt.elemType {
+				t.Errorf("expected element type %s, got %s", tt.elemType, xs.ElemType)
+			}
+		})
+	}
+}
+
+func TestInterpreter_ArrayIndexing(t *testing.T) {
+	input := `
+	int[] xs = [10, 20, 30];
+	xs[1] = xs[1] + 5;
+	int first = xs[0];
+	int second = xs[1];
+	uint u = 2;
+	int last = xs[u];
+	string s = "héllo";
+	string ch = s[1];
+	`
+	i := NewInterpreter(nil)
+	i.Execute(input)
+
+	expected := map[string]any{
+		"first":  int64(10),
+		"second": int64(25),
+		"last":   int64(30),
+		"ch":     "é",
+	}
+	for name, want := range expected {
+		if got := i.Variables[name].Value; got != want {
+			t.Errorf("%s: expected %v, got %v", name, want, got)
+		}
+	}
+}
+
+func TestInterpreter_ArrayElementAssignmentConverts(t *testing.T) {
+	input := `
+	float[2] xs;
+	xs[0] = 3;
+	`
+	i := NewInterpreter(nil)
+	i.Execute(input)
+
+	xs := i.Variables["xs"].Value.(*types.ArrayValue)
+	if val, ok := xs.Elements[0].(float64); !ok || val != 3.0 {
+		t.Errorf("expected 3.0, got %v (%T)", xs.Elements[0], xs.Elements[0])
+	}
+}
+
+func TestInterpreter_ArraysAreShared(t *testing.T) {
+	input := `
+	int[] a = [1, 2];
+	int[] b = a;
+	b[0] = 99;
+	func zero(int[] xs) {
+		xs[1] = 0;
+	}
+	zero(a);
+	`
+	i := NewInterpreter(nil)
+	i.Execute(input)
+
+	a := i.Variables["a"].Value.(*types.ArrayValue)
+	if a.String() != "[99, 0]" {
+		t.Errorf("expected [99, 0], got %s", a.String())
+	}
+}
+
+func TestInterpreter_ArrayForIn(t *testing.T) {
+	input := `
+	int[] xs = [1, 2, 3, 4];
+	int sum = 0;
+	for x in xs {
+		sum = sum + x;
+	}
+	`
+	i := NewInterpreter(nil)
+	i.Execute(input)
+
+	if val := i.Variables["sum"].Value; val != int64(10) {
+		t.Errorf("expected 10, got %v", val)
+	}
+}
+
+func TestInterpreter_ArrayFunctions(t *testing.T) {
+	input := `
+	func rolls(int n) int[] {
+		int(1, 7)[n] r;
+		return r;
+	}
+	func total(int[] xs) int {
+		int t = 0;
+		for x in xs {
+			t = t + x;
+		}
+		return t;
+	}
+	int[] r = rolls(4);
+	int n = len(r);
+	int t = total([1, 2, 3]);
+	`
+	i := NewInterpreter(nil)
+	i.Execute(input)
+
+	if val := i.Variables["n"].Value; val != int64(4) {
+		t.Errorf("expected len 4, got %v", val)
+	}
+	if val := i.Variables["t"].Value; val != int64(6) {
+		t.Errorf("expected total 6, got %v", val)
+	}
+}
+
+func TestInterpreter_ArrayErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+	}{
+		{"index_out_of_bounds", "int[] xs = [1]; int x = xs[1];"},
+		{"negative_index", "int[] xs = [1]; int x = xs[-1];"},
+		{"assign_out_of_bounds", "int[3] xs; xs[3] = 1;"},
+		{"non_integer_index", "int[] xs = [1]; int x = xs[0.5];"},
+		{"size_mismatch", "int[2] xs = [1, 2, 3];"},
+		{"negative_size", "int[-1] xs;"},
+		{"negative_uint_element", "uint[] xs = [1, -2];"},
+		{"wrong_element_type", "int[] xs = [\"a\"];"},
+		{"not_an_array", "int[] xs = 5;"},
+		{"index_non_array", "int x = 5; int y = x[0];"},
+		{"assign_to_string_index", "string s = \"abc\"; s[0] = \"x\";"},
+		{"uninitialized_func_array", "func[2] fs;"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := NewLexer("test", tt.input)
+			p := NewParser(l)
+			program := p.ParseProgram()
+			checkParserErrors(t, p)
+
+			i := NewInterpreter(nil)
+			if _, err := i.Evaluate(program); err == nil {
+				t.Error("expected runtime error, got none")
+			}
+		})
+	}
+}
+
+// ============================================================================
+// Map Tests
+// ============================================================================
+
+func TestInterpreter_MapLiteralsAndIndexing(t *testing.T) {
+	input := `
+	map[string]int scores = {"alice": 3, "bob": 5};
+	scores["carol"] = 9;
+	scores["alice"] = scores["alice"] + 1;
+	int alice = scores["alice"];
+	int n = len(scores);
+	bool hasBob = "bob" in scores;
+	bool hasDave = "dave" in scores;
+	`
+	i := NewInterpreter(nil)
+	i.Execute(input)
+
+	scores, ok := i.Variables["scores"].Value.(*types.MapValue)
+	if !ok {
+		t.Fatalf("expected map, got %T", i.Variables["scores"].Value)
+	}
+	if i.Variables["scores"].Type != types.Map {
+		t.Errorf("expected variable type map, got %s", i.Variables["scores"].Type)
+	}
+	if scores.String() != "{alice: 4, bob: 5, carol: 9}" {
+		t.Errorf("unexpected map contents: %s", scores.String())
+	}
+
+	expected := map[string]any{
+		"alice":   int64(4),
+		"n":       int64(3),
+		"hasBob":  true,
+		"hasDave": false,
+	}
+	for name, want := range expected {
+		if got := i.Variables[name].Value; got != want {
+			t.Errorf("%s: expected %v, got %v", name, want, got)
+		}
+	}
+}
+
+func TestInterpreter_MapConversions(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+		typeName string
+	}{
+		{"declared_types", `map[string]float m = {"a": 1, "b": 2.5};`, "{a: 1.000000, b: 2.500000}", "map[string]float"},
+		{"empty_literal", "map[int]bool m = {};", "{}", "map[int]bool"},
+		{"empty_declaration", "map[string]int m;", "{}", "map[string]int"},
+		{"float_keys", "map[float]int m = {1: 10};", "{1.000000: 10}", "map[float]int"},
+		{"copy_from_variable", `map[string]int a = {"x": 1}; map[string]uint m = a;`, "{x: 1}", "map[string]uint"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			i := NewInterpreter(nil)
+			i.Execute(tt.input)
+
+			m, ok := i.Variables["m"].Value.(*types.MapValue)
+			if !ok {
+				t.Fatalf("expected map, got %T", i.Variables["m"].Value)
+			}
+			if m.String() != tt.expected {
+				t.Errorf("expected %s, got %s", tt.expected, m.String())
+			}
+			if m.TypeString() != tt.typeName {
+				t.Errorf("expected %s, got %s", tt.typeName, m.TypeString())
+			}
+		})
+	}
+}
+
+func TestInterpreter_MapRandomPopulation(t *testing.T) {
+	input := `
+	seed(99);
+	map(3, 8)[string]uint m;
+	`
+	i := NewInterpreter(nil)
+	i.Execute(input)
+
+	m := i.Variables["m"].Value.(*types.MapValue)
+	if m.Len() < 3 || m.Len() >= 8 {
+		t.Fatalf("expected between 3 and 7 entries, got %d", m.Len())
+	}
+	for _, k := range m.Keys() {
+		key, ok := k.(string)
+		if !ok || len(key) != int(i.Config.Length.Min) {
+			t.Errorf("expected random string key, got %v", k)
+		}
+		val, _ := m.Get(k)
+		if v, ok := val.(uint64); !ok || v > i.Config.Uint.Max {
+			t.Errorf("expected uint value in default range, got %v", val)
+		}
+	}
+
+	// The same seed yields the same entries in the same order
+	i2 := NewInterpreter(nil)
+	i2.Execute(input)
+	if got := i2.Variables["m"].Value.(*types.MapValue).String(); got != m.String() {
+		t.Errorf("expected %s for the same seed, got %s", m.String(), got)
+	}
+}
+
+func TestInterpreter_MapForIn(t *testing.T) {
+	input := `
+	map[string]int m = {"c": 3, "a": 1, "b": 2};
+	string order = "";
+	int sum = 0;
+	for k in m {
+		order = order + k;
+		sum = sum + m[k];
+		m[k + k] = 0;
+	}
+	`
+	i := NewInterpreter(nil)
+	i.Execute(input)
+
+	if val := i.Variables["order"].Value; val != "cab" {
+		t.Errorf("expected insertion order cab, got %v", val)
+	}
+	if val := i.Variables["sum"].Value; val != int64(6) {
+		t.Errorf("expected 6, got %v", val)
+	}
+}
+
+func TestInterpreter_MapFunctions(t *testing.T) {
+	input := `
+	func invert(map[string]int m) map[int]string {
+		map[int]string out;
+		for k in m {
+			out[m[k]] = k;
+		}
+		return out;
+	}
+	map[int]string inv = invert({"one": 1, "two": 2});
+	string two = inv[2];
+	`
+	i := NewInterpreter(nil)
+	i.Execute(input)
+
+	if val := i.Variables["two"].Value; val != "two" {
+		t.Errorf("expected two, got %v", val)
+	}
+}
+
+func TestInterpreter_MapErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+	}{
+		{"missing_key", `map[string]int m; int x = m["a"];`},
+		{"wrong_key_type", `map[string]int m; m[1] = 1;`},
+		{"wrong_value_type", `map[string]int m; m["a"] = "b";`},
+		{"negative_uint_value", `map[string]uint m = {"a": -1};`},
+		{"not_a_map", "map[string]int m = 5;"},
+		{"in_non_map", "int[] xs = [1]; bool b = 1 in xs;"},
+		{"too_few_distinct_keys", "map(3, 5)[bool]int m;"},
+		{"invalid_size_range", "map(5, 1)[int]int m;"},
+		{"invalid_literal_key", "int[] xs = [1]; print({xs: 1});"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := NewLexer("test", tt.input)
+			p := NewParser(l)
+			program := p.ParseProgram()
+			checkParserErrors(t, p)
+
+			i := NewInterpreter(nil)
+			if _, err := i.Evaluate(program); err == nil {
+				t.Error("expected runtime error, got none")
+			}
+		})
+	}
+}
+
+// ============================================================================
+// Struct Tests
+// ============================================================================
+
+func TestInterpreter_StructRandomFields(t *testing.T) {
+	input := `
+	seed(7);
+	struct User {
+		string name;
+		int(18, 99) age;
+		float(0, 1) score;
+		bool active;
+		int(1, 7)[3] dice;
+	}
+	User u;
+	`
+	i := NewInterpreter(nil)
+	i.Execute(input)
+
+	u, ok := i.Variables["u"].Value.(*types.StructValue)
+	if !ok {
+		t.Fatalf("expected struct, got %T", i.Variables["u"].Value)
+	}
+	if i.Variables["u"].Type != types.Struct || u.TypeName != "User" {
+		t.Errorf("expected a User struct variable, got %s %s", i.Variables["u"].Type, u.TypeName)
+	}
+	if fields := u.Fields(); len(fields) != 5 || fields[0] != "name" || fields[4] != "dice" {
+		t.Errorf("expected fields in declaration order, got %v", fields)
+	}
+
+	if name, _ := u.Get("name"); len(name.(string)) != int(i.Config.Length.Min) {
+		t.Errorf("expected random string name, got %v", name)
+	}
+	if age, _ := u.Get("age"); age.(int64) < 18 || age.(int64) >= 99 {
+		t.Errorf("expected age in [18, 99), got %v", age)
+	}
+	if score, _ := u.Get("score"); score.(float64) < 0 || score.(float64) >= 1 {
+		t.Errorf("expected score in [0, 1), got %v", score)
+	}
+	if _, ok := u.Get("active"); !ok {
+		t.Error("expected field active")
+	}
+	dice, _ := u.Get("dice")
+	for _, d := range dice.(*types.ArrayValue).Elements {
+		if d.(int64) < 1 || d.(int64) >= 7 {
+			t.Errorf("expected die in [1, 7), got %v", d)
+		}
+	}
+
+	// The same seed yields the same instance
+	i2 := NewInterpreter(nil)
+	i2.Execute(input)
+	if got := i2.Variables["u"].Value.(*types.StructValue).String(); got != u.String() {
+		t.Errorf("expected %s for the same seed, got %s", u.String(), got)
+	}
+}
+
+func TestInterpreter_StructFieldAccessAndAssignment(t *testing.T) {
+	input := `
+	struct Address { string city; uint number; }
+	struct User { int age; Address home; }
+	User u;
+	u.age = 30;
+	u.age = u.age + 1;
+	u.home.city = "Berlin";
+	u.home.number = 7;
+	int age = u.age;
+	string city = u.home.city;
+	string kind = typeof(u);
+	`
+	i := NewInterpreter(nil)
+	i.Execute(input)
+
+	expected := map[string]any{
+		"age":  int64(31),
+		"city": "Berlin",
+		"kind": "User",
+	}
+	for name, want := range expected {
+		if got := i.Variables[name].Value; got != want {
+			t.Errorf("%s: expected %v, got %v", name, want, got)
+		}
+	}
+
+	home, _ := i.Variables["u"].Value.(*types.StructValue).Get("home")
+	if number, _ := home.(*types.StructValue).Get("number"); number != uint64(7) {
+		t.Errorf("expected uint 7, got %v (%T)", number, number)
+	}
+}
+
+func TestInterpreter_StructFieldStrictness(t *testing.T) {
+	input := `
+	struct Counter { uint hits; unofloat ratio; float total; }
+	Counter c;
+	int five = 5;
+	c.hits = five - 10;
+	c.ratio = 0.5 + 0.75;
+	c.total = 2;
+	`
+	i := NewInterpreter(nil)
+	i.Execute(input)
+
+	c := i.Variables["c"].Value.(*types.StructValue)
+	if hits, _ := c.Get("hits"); hits != uint64(18446744073709551611) {
+		t.Errorf("expected computed uint to underflow, got %v", hits)
+	}
+	if ratio, _ := c.Get("ratio"); ratio != types.UnofloatType(1) {
+		t.Errorf("expected computed unofloat to clamp to 1, got %v", ratio)
+	}
+	if total, _ := c.Get("total"); total != float64(2) {
+		t.Errorf("expected int to be cast to float, got %v (%T)", total, total)
+	}
+}
+
+func TestInterpreter_StructsAreShared(t *testing.T) {
+	input := `
+	struct Point { int x = 0; int y = 0; }
+	func moveRight(Point p) Point {
+		p.x = p.x + 1;
+		return p;
+	}
+	Point a;
+	Point b = moveRight(a);
+	Point[2] points;
+	points[0].y = 5;
+	int ax = a.x;
+	int bx = b.x;
+	int y = points[0].y;
+	int otherY = points[1].y;
+	`
+	i := NewInterpreter(nil)
+	i.Execute(input)
+
+	expected := map[string]any{
+		"ax":     int64(1),
+		"bx":     int64(1),
+		"y":      int64(5),
+		"otherY": int64(0),
+	}
+	for name, want := range expected {
+		if got := i.Variables[name].Value; got != want {
+			t.Errorf("%s: expected %v, got %v", name, want, got)
+		}
+	}
+}
+
+func TestInterpreter_StructFieldDeclaredLater(t *testing.T) {
+	input := `
+	struct Line { Point from; Point[2] ends; }
+	struct Point { int x = 3; int y = 4; }
+	Line l;
+	int x = l.from.x;
+	int y = l.ends[1].y;
+	`
+	i := NewInterpreter(nil)
+	i.Execute(input)
+
+	expected := map[string]any{"x": int64(3), "y": int64(4)}
+	for name, want := range expected {
+		if got := i.Variables[name].Value; got != want {
+			t.Errorf("%s: expected %v, got %v", name, want, got)
+		}
+	}
+}
+
+func TestInterpreter_StructErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+	}{
+		{"unknown_field", "struct P { int x; } P p; int y = p.y;"},
+		{"assign_unknown_field", "struct P { int x; } P p; p.y = 1;"},
+		{"field_type_mismatch", `struct P { int x; } P p; p.x = "a";`},
+		{"negative_uint_field", "struct P { uint n; } P p; p.n = -1;"},
+		{"unofloat_field_out_of_range", "struct P { unofloat r; } P p; p.r = 2.0;"},
+		{"negative_uint_initializer", "struct P { uint n = -1; } P p;"},
+		{"invalid_field_range", "struct P { int(5, 1) x; } P p;"},
+		{"redeclared_struct", "struct P { int x; } struct P { int y; }"},
+		{"self_containing", "struct P { P next; } P p;"},
+		{"ranged_struct", "struct P { int x; } P(1, 2) p;"},
+		{"wrong_struct_type", "struct P { int x; } struct Q { int x; } P p; Q q = p;"},
+		{"field_of_non_struct", "int n = 1; int m = n.x;"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := NewLexer("test", tt.input)
+			p := NewParser(l)
+			program := p.ParseProgram()
+			checkParserErrors(t, p)
+
+			i := NewInterpreter(nil)
+			if _, err := i.Evaluate(program); err == nil {
+				t.Error("expected runtime error, got none")
+			}
+		})
+	}
+}
+
+// ============================================================================
+// Constant Tests
+// ============================================================================
+
+func TestInterpreter_ConstDeclarations(t *testing.T) {
+	input := `
+	const int(1, 7) ROLL;
+	const float PI = 3.14;
+	const int[] PRIMES = [2, 3, 5];
+	PRIMES[0] = 7;
+	int first = ROLL;
+	int second = ROLL;
+	if (true) {
+		int ROLL = 100; // shadowing a constant declares a new variable
+		ROLL = 200;
+	}
+	`
+	i := NewInterpreter(nil)
+	i.Execute(input)
+
+	roll := i.Variables["ROLL"]
+	if !roll.Const {
+		t.Error("expected ROLL to be constant")
+	}
+	if v := roll.Value.(int64); v < 1 || v >= 7 {
+		t.Errorf("expected ROLL in [1, 7), got %d", v)
+	}
+	if i.Variables["first"].Value != roll.Value || i.Variables["second"].Value != roll.Value {
+		t.Errorf("expected ROLL to be drawn once, got %v and %v", i.Variables["first"].Value, i.Variables["second"].Value)
+	}
+	if v := i.Variables["PI"].Value; v != 3.14 {
+		t.Errorf("expected 3.14, got %v", v)
+	}
+	// Constants fix the variable, not the contents of the array it refers to
+	if v := i.Variables["PRIMES"].Value.(*types.ArrayValue).Elements[0]; v != int64(7) {
+		t.Errorf("expected 7, got %v", v)
+	}
+}
+
+func TestInterpreter_ConstAssignmentErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		input  string
+		line   int
+		column int
+	}{
+		{"reassign", "const float PI = 3.14;\nPI = 3;", 2, 1},
+		{"reassign_random", "const int(1, 6) ROLL;\nROLL = ROLL + 1;", 2, 1},
+		{"reassign_in_block", "const int N = 1;\nif (true) {\n  N = 2;\n}", 3, 3},
+		{"reassign_in_function", "const int N = 1;\nfunc f() {\n  N = 2;\n}\nf();", 3, 3},
+		{"loop_post", "const int i = 0;\nfor (; i < 3; i = i + 1) {}", 2, 15},
+		{"const_field", "struct P { const int id = 1; }\nP p;\np.id = 2;", 3, 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := NewLexer("test", tt.input)
+			p := NewParser(l)
+			program := p.ParseProgram()
+			checkParserErrors(t, p)
+
+			i := NewInterpreter(nil)
+			_, err := i.Evaluate(program)
+			rtErr, ok := err.(*RuntimeError)
+			if !ok {
+				t.Fatalf("expected runtime error, got %v", err)
+			}
+			if rtErr.Line != tt.line || rtErr.Column != tt.column {
+				t.Errorf("expected error at %d:%d, got %d:%d", tt.line, tt.column, rtErr.Line, rtErr.Column)
+			}
+		})
+	}
+}
+
+// ============================================================================
+// Match Tests
+// ============================================================================
+
+func TestInterpreter_MatchPatterns(t *testing.T) {
+	tests := []struct {
+		name     string
+		subject  string
+		expected string
+	}{
+		{"range_start", "90", "A"},
+		{"range_end", "89", "B"},
+		{"literal", "42", "answer"},
+		{"negative_range", "-3", "negative"},
+		{"float_subject", "85.5", "B"},
+		{"default", "12", "F"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			input := `
+			string grade = "none";
+			match ` + tt.subject + ` {
+				90..100 => { grade = "A"; },
+				80..89 => { grade = "B"; },
+				42 => { grade = "answer"; },
+				-10..-1 => { grade = "negative"; },
+				_ => { grade = "F"; },
+			}
+			`
+			i := NewInterpreter(nil)
+			i.Execute(input)
+
+			if got := i.Variables["grade"].Value; got != tt.expected {
+				t.Errorf("expected %s, got %v", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestInterpreter_MatchStrings(t *testing.T) {
+	input := `
+	string answer = "";
+	func reply(string s) string {
+		string r = "?";
+		match s {
+			"hi" => { r = "hello"; },
+			"bye" => { r = "goodbye"; },
+			"a".."m" => { r = "early"; }
+		}
+		return r;
+	}
+	string a = reply("hi");
+	string b = reply("bye");
+	string c = reply("cat");
+	string d = reply("zebra");
+	`
+	i := NewInterpreter(nil)
+	i.Execute(input)
+
+	expected := map[string]any{"a": "hello", "b": "goodbye", "c": "early", "d": "?"}
+	for name, want := range expected {
+		if got := i.Variables[name].Value; got != want {
+			t.Errorf("%s: expected %v, got %v", name, want, got)
+		}
+	}
+}
+
+func TestInterpreter_MatchEvaluatesSubjectOnce(t *testing.T) {
+	input := `
+	int calls = 0;
+	func next() int {
+		calls = calls + 1;
+		return calls * 10;
+	}
+	string hit = "";
+	match next() {
+		20 => { hit = "twenty"; },
+		10 => { hit = "ten"; },
+		_ => { hit = "other"; },
+	}
+	`
+	i := NewInterpreter(nil)
+	i.Execute(input)
+
+	if calls := i.Variables["calls"].Value; calls != int64(1) {
+		t.Errorf("expected the subject to be evaluated once, got %v calls", calls)
+	}
+	if hit := i.Variables["hit"].Value; hit != "ten" {
+		t.Errorf("expected ten, got %v", hit)
+	}
+}
+
+func TestInterpreter_MatchInLoop(t *testing.T) {
+	input := `
+	int sum = 0;
+	for k in 1..10 {
+		match k {
+			3 => { continue; },
+			6 => { break; },
+			_ => { sum = sum + k; },
+		}
+	}
+	`
+	i := NewInterpreter(nil)
+	i.Execute(input)
+
+	// 1 + 2 + 4 + 5: continue and break apply to the enclosing loop
+	if sum := i.Variables["sum"].Value; sum != int64(12) {
+		t.Errorf("expected 12, got %v", sum)
+	}
+}
+
+func TestInterpreter_MatchErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+	}{
+		{"type_mismatch", `match 1 { "a" => { } }`},
+		{"bool_range", "match true { 1..2 => { } }"},
+		{"undefined_subject", "match nope { _ => { } }"},
+		{"error_in_arm", "match 1 { 1 => { int x = 1 / 0; } }"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := NewLexer("test", tt.input)
+			p := NewParser(l)
+			program := p.ParseProgram()
+			checkParserErrors(t, p)
+
+			i := NewInterpreter(nil)
+			if _, err := i.Evaluate(program); err == nil {
+				t.Error("expected runtime error, got none")
+			}
+		})
+	}
+}
+
+func TestInterpreter_ChooseSingleArm(t *testing.T) {
+	input := `
+	string picked = "";
+	choose {
+		1 => { picked = "only"; }
+	}
+	`
+	i := NewInterpreter(nil)
+	i.Execute(input)
+
+	if got := i.Variables["picked"].Value; got != "only" {
+		t.Errorf("expected only, got %v", got)
+	}
+}
+
+func TestInterpreter_ChooseDistribution(t *testing.T) {
+	input := `
+	seed(7);
+	int a = 0;
+	int b = 0;
+	int c = 0;
+	repeat 10000 {
+		choose {
+			3 => { a = a + 1; },
+			5 => { b = b + 1; },
+			2.0 => { c = c + 1; },
+		}
+	}
+	`
+	i := NewInterpreter(nil)
+	i.Execute(input)
+
+	// Every iteration picks exactly one arm, roughly in a 30/50/20 split
+	a := i.Variables["a"].Value.(int64)
+	b := i.Variables["b"].Value.(int64)
+	c := i.Variables["c"].Value.(int64)
+	if a+b+c != 10000 {
+		t.Fatalf("expected 10000 picks, got %d", a+b+c)
+	}
+	for name, got := range map[string]int64{"a": a, "b": b, "c": c} {
+		want := map[string]int64{"a": 3000, "b": 5000, "c": 2000}[name]
+		if got < want-300 || got > want+300 {
+			t.Errorf("%s: expected about %d picks, got %d", name, want, got)
+		}
+	}
+}
+
+func TestInterpreter_ChooseDeterministic(t *testing.T) {
+	input := `
+	seed(12345);
+	string result = "";
+	for k in 1..20 {
+		choose {
+			1 => { result = result + "a"; },
+			1 => { result = result + "b"; },
+			1 => { result = result + "c"; },
+		}
+	}
+	`
+
+	i1 := NewInterpreter(nil)
+	i1.Execute(input)
+	i2 := NewInterpreter(nil)
+	i2.Execute(input)
+
+	if v1, v2 := i1.Variables["result"].Value, i2.Variables["result"].Value; v1 != v2 {
+		t.Errorf("choose not deterministic with same seed: %v vs %v", v1, v2)
+	}
+}
+
+func TestInterpreter_ChooseErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+	}{
+		{"zero_weight", "choose { 1 => { }, 0 => { } }"},
+		{"negative_weight", "choose { 1 => { }, -2 => { } }"},
+		{"all_zero", "choose { 0 => { }, 0.0 => { } }"},
+		{"string_weight", `choose { "3" => { } }`},
+		{"undefined_weight", "choose { w => { } }"},
+		{"error_in_arm", "choose { 1 => { int x = 1 / 0; } }"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := NewLexer("test", tt.input)
+			p := NewParser(l)
+			program := p.ParseProgram()
+			checkParserErrors(t, p)
+
+			i := NewInterpreter(nil)
+			if _, err := i.Evaluate(program); err == nil {
+				t.Error("expected runtime error, got none")
+			}
+		})
+	}
+}
+
+// ============================================================================
+// String Interpolation Tests
+// ============================================================================
+
+func TestInterpreter_StringInterpolation(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{"int", `"rolled ${dice} on try ${i}"`, "rolled 4 on try 2"},
+		{"expression", `"${dice * i + 1}"`, "9"},
+		{"float_like_print", `"${ratio}"`, "0.500000"},
+		{"unofloat_like_print", `"${u}"`, "0.250000"},
+		{"bool_and_string", `"${ok}/${name}"`, "true/bob"},
+		{"array", `"${xs}"`, "[1, 2]"},
+		{"float_array_like_print", `"${fs}"`, "[1.500000, 2.000000]"},
+		{"float_map_like_print", `"${m}"`, "{a: 0.500000}"},
+		{"struct_like_print", `"${p}"`, "P{x: 0.100000}"},
+		{"nested", `"a ${"b ${dice}"} c"`, "a b 4 c"},
+		{"ternary", `"${dice > 3 ? "high" : "low"}"`, "high"},
+		{"call", `"len=${len(name)}"`, "len=3"},
+		{"escaped_dollar", `"\${dice}"`, "${dice}"},
+		{"escapes_in_text", `"\"${name}\"\t!"`, "\"bob\"\t!"},
+		{"only_expression", `"${name}"`, "bob"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			input := `
+			int dice = 4;
+			int i = 2;
+			float ratio = 0.5;
+			unofloat u = 0.25;
+			bool ok = true;
+			string name = "bob";
+			int[2] xs = [1, 2];
+			float[] fs = [1.5, 2];
+			map[string]float m = {"a": 0.5};
+			struct P { float x = 0.1; }
+			P p;
+			string result = ` + tt.input + `;
+			`
+			i := NewInterpreter(nil)
+			i.Execute(input)
+
+			if got := i.Variables["result"].Value; got != tt.expected {
+				t.Errorf("expected %q, got %q", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestInterpreter_MultiLineTemplate(t *testing.T) {
+	input := "string name = \"Ada\";\nint n = 3;\nstring letter = \"\"\"\nDear ${name},\n\tyou rolled ${n} \"sixes\".\n\"\"\";\nstring path = `C:\\dice\\${n}`;"
+	i := NewInterpreter(nil)
+	i.Execute(input)
+
+	if got := i.Variables["letter"].Value; got != "Dear Ada,\n\tyou rolled 3 \"sixes\".\n" {
+		t.Errorf("unexpected letter %q", got)
+	}
+	if got := i.Variables["path"].Value; got != `C:\dice\${n}` {
+		t.Errorf("unexpected path %q", got)
+	}
+}
+
+func TestInterpreter_InterpolationEvaluatesEachTime(t *testing.T) {
+	input := `
+	string log = "";
+	for k in 1..3 {
+		log = log + "${k}:${k * k} ";
+	}
+	`
+	i := NewInterpreter(nil)
+	i.Execute(input)
+
+	if got := i.Variables["log"].Value; got != "1:1 2:4 3:9 " {
+		t.Errorf("unexpected log %q", got)
+	}
+}
+
+func TestInterpreter_InterpolationErrors(t *testing.T) {
+	input := "int x = 0;\nstring s = \"a ${missing} b\";"
+	l := NewLexer("test", input)
+	p := NewParser(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	i := NewInterpreter(nil)
+	_, err := i.Evaluate(program)
+	rtErr, ok := err.(*RuntimeError)
+	if !ok {
+		t.Fatalf("expected *RuntimeError, got %T", err)
+	}
+	if rtErr.Kind != ErrorKindUndefined || rtErr.Line != 2 || rtErr.Column != 17 {
+		t.Errorf("expected an undefined error at 2:17, got %s at %d:%d", rtErr.Kind, rtErr.Line, rtErr.Column)
+	}
+}
+
+func TestInterpreter_TypedNumericLiterals(t *testing.T) {
+	// The suffix sets the type of the left operand, and with it the type of the result
+	input := `
+	int x = 10;
+	int diff = x-4;
+	string sumType = typeof(5u + x);
+	string halfType = typeof(50% * x);
+	unofloat chance = 30%;
+	uint mask = 0xFF_FFu;
+	bool always = false;
+	ifrand(100%) { always = true; }
+	`
+	i := NewInterpreter(nil)
+	i.Execute(input)
+
+	expected := map[string]any{
+		"diff":     int64(6),
+		"sumType":  "uint",
+		"halfType": "unofloat",
+		"chance":   types.UnofloatType(0.3),
+		"mask":     uint64(0xFFFF),
+		"always":   true,
+	}
+	for name, want := range expected {
+		v, ok := i.Variables[name]
+		if !ok {
+			t.Fatalf("variable '%s' not found", name)
+		}
+		if v.Value != want {
+			t.Errorf("%s: expected %v (%T), got %v (%T)", name, want, want, v.Value, v.Value)
+		}
+	}
+}
+
+// ============================================================================
+// Error Handling Tests
+// ============================================================================
+
+func TestInterpreter_TryCatchKinds(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		kind string
+	}{
+		{"division_by_zero", "int x = 1 / 0;", "division_by_zero"},
+		{"type_mismatch", `bool b = "yes";`, "type_mismatch"},
+		{"undefined_variable", "print(missing);", "undefined"},
+		{"undefined_function", "missing();", "undefined"},
+		{"invalid_range", "int(5, 1) r;", "invalid_range"},
+		{"index_out_of_bounds", "int[2] xs; int v = xs[5];", "index_out_of_bounds"},
+		{"invalid_value", "uint u = -1;", "invalid_value"},
+		{"volatile_assignment", "volatile int(1, 7) die; die = 3;", "volatile_assignment"},
+		{"thrown", `throw "custom";`, "thrown"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			input := `
+			string kind = "";
+			try {
+				` + tt.body + `
+			} catch (err) {
+				kind = err.kind;
+			}
+			`
+			i := NewInterpreter(nil)
+			i.Execute(input)
+
+			if got := i.Variables["kind"].Value; got != tt.kind {
+				t.Errorf("expected %s, got %v", tt.kind, got)
+			}
+		})
+	}
+}
+
+func TestInterpreter_CaughtErrorFields(t *testing.T) {
+	input := `
+	string message = "";
+	int line = 0;
+	int column = 0;
+	string typeName = "";
+	try {
+		int x = 1;
+		throw "bad roll";
+	} catch (err) {
+		message = err.message;
+		line = err.line;
+		column = err.column;
+		typeName = typeof(err);
+	}
+	`
+	i := NewInterpreter(nil)
+	i.Execute(input)
+
+	expected := map[string]any{"message": "bad roll", "line": int64(8), "column": int64(3), "typeName": ErrorTypeName}
+	for name, want := range expected {
+		if got := i.Variables[name].Value; got != want {
+			t.Errorf("%s: expected %v, got %v", name, want, got)
+		}
+	}
+}
+
+func TestInterpreter_TryCatchCountsErrors(t *testing.T) {
+	input := `
+	seed(3);
+	int errors = 0;
+	int ok = 0;
+	repeat 100 {
+		int(0, 3) divisor;
+		try {
+			int q = 12 / divisor;
+			ok = ok + 1;
+		} catch {
+			errors = errors + 1;
+		}
+	}
+	`
+	i := NewInterpreter(nil)
+	i.Execute(input)
+
+	errs := i.Variables["errors"].Value.(int64)
+	ok := i.Variables["ok"].Value.(int64)
+	if errs+ok != 100 || errs == 0 || ok == 0 {
+		t.Errorf("expected a mix of 100 successes and errors, got %d ok and %d errors", ok, errs)
+	}
+}
+
+func TestInterpreter_TryCatchScoping(t *testing.T) {
+	input := `
+	string err = "outer";
+	int x = 0;
+	try {
+		int x = 5;
+		throw "inner";
+	} catch (err) {
+		x = x + 1;
+	}
+	`
+	i := NewInterpreter(nil)
+	i.Execute(input)
+
+	// The catch parameter shadows err and the try block's x never leaks out
+	if got := i.Variables["err"].Value; got != "outer" {
+		t.Errorf("expected err to stay outer, got %v", got)
+	}
+	if got := i.Variables["x"].Value; got != int64(1) {
+		t.Errorf("expected x to be 1, got %v", got)
+	}
+}
+
+func TestInterpreter_TryDoesNotCatchControlFlow(t *testing.T) {
+	input := `
+	func first() int {
+		for k in 1..10 {
+			try {
+				if (k == 3) { return k; }
+				if (k == 1) { continue; }
+			} catch {
+				return -1;
+			}
+		}
+		return 0;
+	}
+	int got = first();
+	int seen = 0;
+	while (true) {
+		try { seen = seen + 1; break; } catch { }
+	}
+	`
+	i := NewInterpreter(nil)
+	i.Execute(input)
+
+	if got := i.Variables["got"].Value; got != int64(3) {
+		t.Errorf("expected 3, got %v", got)
+	}
+	if seen := i.Variables["seen"].Value; seen != int64(1) {
+		t.Errorf("expected 1, got %v", seen)
+	}
+}
+
+func TestInterpreter_NestedTryAndRethrow(t *testing.T) {
+	input := `
+	string log = "";
+	func risky() int {
+		return 1 / 0;
+	}
+	try {
+		try {
+			risky();
+		} catch (inner) {
+			log = inner.kind;
+			throw "wrapped: " + inner.message;
+		}
+	} catch (outer) {
+		log = log + " -> " + outer.message;
+	}
+	`
+	i := NewInterpreter(nil)
+	i.Execute(input)
+
+	if got := i.Variables["log"].Value; got != "division_by_zero -> wrapped: division by zero" {
+		t.Errorf("unexpected log %v", got)
+	}
+}
+
+func TestInterpreter_ThrowErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		kind  ErrorKind
+	}{
+		{"uncaught", `throw "boom";`, ErrorKindThrown},
+		{"not_a_string", "throw 42;", ErrorKindTypeMismatch},
+		{"error_in_handler", "try { throw \"a\"; } catch { int x = 1 / 0; }", ErrorKindDivisionByZero},
+		{"read_only_error", "try { throw \"a\"; } catch (e) { e.kind = \"b\"; }", ErrorKindRuntime},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := NewLexer("test", tt.input)
+			p := NewParser(l)
+			program := p.ParseProgram()
+			checkParserErrors(t, p)
+
+			i := NewInterpreter(nil)
+			_, err := i.Evaluate(program)
+			rtErr, ok := err.(*RuntimeError)
+			if !ok {
+				t.Fatalf("expected *RuntimeError, got %T", err)
+			}
+			if rtErr.Kind != tt.kind {
+				t.Errorf("expected kind %s, got %s", tt.kind, rtErr.Kind)
+			}
+		})
+	}
+}
+
+// ============================================================================
+// Assertion Tests
+// ============================================================================
+
+func TestInterpreter_AssertPasses(t *testing.T) {
+	input := `
+	int(1, 7) die;
+	assert(die >= 1 && die <= 6, "die out of range");
+	assert(true);
+	assert(len("abc") == 3);
+	int after = 1;
+	`
+	i := NewInterpreter(nil)
+	i.Execute(input)
+
+	if after := i.Variables["after"].Value; after != int64(1) {
+		t.Errorf("expected the script to continue after passing asserts, got %v", after)
+	}
+}
+
+func TestInterpreter_AssertFailure(t *testing.T) {
+	input := "int x = -3;\nint y = 2;\n  assert(x * 2 > y, \"x must be positive\");"
+	l := NewLexer("test", input)
+	p := NewParser(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	i := NewInterpreter(nil)
+	_, err := i.Evaluate(program)
+	assertErr, ok := err.(*AssertionError)
+	if !ok {
+		t.Fatalf("expected *AssertionError, got %T: %v", err, err)
+	}
+
+	if assertErr.Line != 3 || assertErr.Column != 3 {
+		t.Errorf("expected the error at 3:3, got %d:%d", assertErr.Line, assertErr.Column)
+	}
+	if assertErr.Expr != "((x * 2) > y)" {
+		t.Errorf("unexpected expression text %q", assertErr.Expr)
+	}
+	if assertErr.Msg != "x must be positive" {
+		t.Errorf("unexpected message %q", assertErr.Msg)
+	}
+	expected := []AssertionOperand{{"(x * 2)", int64(-6)}, {"y", int64(2)}}
+	if len(assertErr.Operands) != len(expected) {
+		t.Fatalf("expected %d operands, got %v", len(expected), assertErr.Operands)
+	}
+	for idx, want := range expected {
+		if got := assertErr.Operands[idx]; got != want {
+			t.Errorf("operand %d: expected %v, got %v", idx, want, got)
+		}
+	}
+	if !strings.Contains(err.Error(), "[(x * 2) = -6, y = 2]") {
+		t.Errorf("expected the operand values in %q", err.Error())
+	}
+}
+
+func TestInterpreter_AssertEvaluatesOperandsOnce(t *testing.T) {
+	input := `
+	int calls = 0;
+	func next() int {
+		calls = calls + 1;
+		return calls;
+	}
+	assert(next() < 0);
+	`
+	l := NewLexer("test", input)
+	p := NewParser(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	i := NewInterpreter(nil)
+	_, err := i.Evaluate(program)
+	assertErr, ok := err.(*AssertionError)
+	if !ok {
+		t.Fatalf("expected *AssertionError, got %T: %v", err, err)
+	}
+	if calls := i.Variables["calls"].Value; calls != int64(1) {
+		t.Errorf("expected 1 call, got %v", calls)
+	}
+	if got := assertErr.Operands[0]; got.Value != int64(1) {
+		t.Errorf("expected the reported operand to be 1, got %v", got.Value)
+	}
+}
+
+func TestInterpreter_AssertIsNotCaught(t *testing.T) {
+	input := `
+	string caught = "no";
+	try {
+		assert(1 > 2);
+	} catch {
+		caught = "yes";
+	}
+	`
+	l := NewLexer("test", input)
+	p := NewParser(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	i := NewInterpreter(nil)
+	if _, err := i.Evaluate(program); err == nil {
+		t.Fatal("expected the assertion to fail the script")
+	}
+	if caught := i.Variables["caught"].Value; caught != "no" {
+		t.Errorf("expected the assertion to pass through catch, got %v", caught)
+	}
+}
+
+func TestInterpreter_AssertErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+	}{
+		{"non_bool_condition", "assert(1);"},
+		{"non_string_message", "assert(false, 42);"},
+		{"error_in_condition", "assert(1 / 0 == 1);"},
+		{"error_in_message", "assert(false, missing);"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := NewLexer("test", tt.input)
+			p := NewParser(l)
+			program := p.ParseProgram()
+			checkParserErrors(t, p)
+
+			i := NewInterpreter(nil)
+			_, err := i.Evaluate(program)
+			if _, ok := err.(*RuntimeError); !ok {
+				t.Errorf("expected a runtime error, got %T: %v", err, err)
+			}
+		})
+	}
+}
+
+func TestInterpreter_ExecuteFileReportsFailure(t *testing.T) {
+	tests := []struct {
+		name   string
+		input  string
+		failed bool
+	}{
+		{"success", "assert(true);", false},
+		{"assertion", "assert(false);", true},
+		{"runtime_error", "int x = 1 / 0;", true},
+		{"parser_error", "int x = ;", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			i := NewInterpreter(nil)
+			if err := i.ExecuteFile("", tt.input); (err != nil) != tt.failed {
+				t.Errorf("expected failure %v, got %v", tt.failed, err)
+			}
+		})
+	}
+}
+
+// ============================================================================
+// Module Tests
+// ============================================================================
+
+// writeModules creates the given files in a temporary directory and returns its path
+func writeModules(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+// evalFile parses and evaluates a file written by writeModules
+func evalFile(t *testing.T, i *Interpreter, path string) error {
+	t.Helper()
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	l := NewLexer(path, string(content))
+	p := NewParser(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	_, err = i.Evaluate(program)
+	return err
+}
+
+func TestInterpreter_ImportNamespacedAccess(t *testing.T) {
+	dir := writeModules(t, map[string]string{
+		"lib/dice.wtf": `
+		import "util.wtf";
+		int SIDES = 6;
+		int loads = 0;
+		func roll() int {
+			return util.clamp(7, 1, SIDES);
+		}
+		`,
+		"lib/util.wtf": `
+		func clamp(int x, int lo, int hi) int {
+			if (x < lo) { return lo; }
+			if (x > hi) { return hi; }
+			return x;
+		}
+		`,
+		"main.wtf": `
+		import "lib/dice.wtf";
+		import "lib/dice.wtf" as d;
+		int sides = dice.SIDES;
+		int rolled = dice.roll();
+		func f = d.roll;
+		int again = f();
+		string kind = typeof(dice);
+		`,
+	})
+
+	i := NewInterpreter(nil)
+	if err := evalFile(t, i, filepath.Join(dir, "main.wtf")); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	expected := map[string]any{
+		"sides":  int64(6),
+		"rolled": int64(6),
+		"again":  int64(6),
+		"kind":   "module",
+	}
+	for name, want := range expected {
+		if got := i.Variables[name].Value; got != want {
+			t.Errorf("%s: expected %v, got %v", name, want, got)
+		}
+	}
+
+	// Both imports share one module, and nested imports do not leak into the importer
+	if i.Variables["dice"].Value != i.Variables["d"].Value {
+		t.Error("expected both imports to share the module")
+	}
+	if _, ok := i.Variables["util"]; ok {
+		t.Error("expected util to be visible only inside dice")
+	}
+	if _, ok := i.Variables["SIDES"]; ok {
+		t.Error("expected module variables to stay in the module")
+	}
+}
+
+func TestInterpreter_ImportEvaluatesOnce(t *testing.T) {
+	dir := writeModules(t, map[string]string{
+		"counter.wtf": `int(0, 1000000) id;`,
+		"a.wtf":       `import "counter.wtf"; int id = counter.id;`,
+		"main.wtf": `
+		import "a.wtf";
+		import "counter.wtf";
+		bool same = a.id == counter.id;
+		`,
+	})
+
+	i := NewInterpreter(nil)
+	if err := evalFile(t, i, filepath.Join(dir, "main.wtf")); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if same := i.Variables["same"].Value; same != true {
+		t.Error("expected counter.wtf to be evaluated once")
+	}
+}
+
+func TestInterpreter_ImportKeepsTypesPerModule(t *testing.T) {
+	dir := writeModules(t, map[string]string{
+		"lib.wtf": `
+		struct P { int(1, 3) a; }
+		enum E { A, B }
+		func mk() P {
+			P p;
+			p.a = 5;
+			return p;
+		}
+		func pick() E {
+			E e;
+			return e;
+		}
+		`,
+		"main.wtf": `
+		import "lib.wtf";
+		struct P { string s; }
+		enum E { A }
+		P mine;
+		mine.s = "mine";
+		int theirs = lib.mk().a;
+		string picked = typeof(lib.pick());
+		`,
+	})
+
+	i := NewInterpreter(nil)
+	if err := evalFile(t, i, filepath.Join(dir, "main.wtf")); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if theirs := i.Variables["theirs"].Value; theirs != int64(5) {
+		t.Errorf("expected 5, got %v", theirs)
+	}
+	if picked := i.Variables["picked"].Value; picked != "E" {
+		t.Errorf("expected E, got %v", picked)
+	}
+
+	// Types with the same name in different modules are different types
+	tests := []string{
+		"import \"lib.wtf\"; struct P { string s; } P p = lib.mk();",
+		"import \"lib.wtf\"; enum E { A, B } bool same = lib.pick() == E.A;",
+	}
+	for _, input := range tests {
+		t.Run(input, func(t *testing.T) {
+			l := NewLexer(filepath.Join(dir, "other.wtf"), input)
+			p := NewParser(l)
+			program := p.ParseProgram()
+			checkParserErrors(t, p)
+
+			_, err := NewInterpreter(nil).Evaluate(program)
+			rErr, ok := err.(*RuntimeError)
+			if !ok || rErr.Kind != ErrorKindTypeMismatch {
+				t.Errorf("expected a type mismatch, got %v", err)
+			}
+		})
+	}
+}
+
+func TestInterpreter_ImportedEnumMembers(t *testing.T) {
+	dir := writeModules(t, map[string]string{
+		"lib.wtf": `
+		enum Color { Red, Green }
+		func pick() Color { return Color.Green; }
+		func isRed(Color c) bool { return c == Color.Red; }
+		`,
+		"main.wtf": `
+		import "lib.wtf";
+		enum Color { Red }
+		string name = "${lib.Color.Red}";
+		bool picked = lib.pick() == lib.Color.Green;
+		bool red = lib.isRed(lib.Color.Red);
+		bool mixed = lib.Color.Red != Color.Red;
+		`,
+	})
+
+	i := NewInterpreter(nil)
+	err := evalFile(t, i, filepath.Join(dir, "main.wtf"))
+	if err == nil {
+		t.Fatal("expected comparing enums of different modules to fail")
+	}
+	if rErr, ok := err.(*RuntimeError); !ok || rErr.Kind != ErrorKindTypeMismatch {
+		t.Errorf("expected a type mismatch, got %v", err)
+	}
+
+	expected := map[string]any{"name": "Red", "picked": true, "red": true}
+	for name, want := range expected {
+		if got := i.Variables[name].Value; got != want {
+			t.Errorf("%s: expected %v, got %v", name, want, got)
+		}
+	}
+}
+
+func TestInterpreter_ImportErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		files    map[string]string
+		contains string
+		file     string
+	}{
+		{
+			"cycle",
+			map[string]string{
+				"main.wtf": `import "a.wtf";`,
+				"a.wtf":    `import "b.wtf";`,
+				"b.wtf":    `import "a.wtf";`,
+			},
+			"import cycle: ", "b.wtf",
+		},
+		{
+			"missing_file",
+			map[string]string{"main.wtf": `import "nope.wtf";`},
+			"cannot import", "main.wtf",
+		},
+		{
+			"unknown_member",
+			map[string]string{"main.wtf": `import "a.wtf"; int y = a.y;`, "a.wtf": "int x = 1;"},
+			"module", "main.wtf",
+		},
+		{
+			"assign_member",
+			map[string]string{"main.wtf": `import "a.wtf"; a.x = 2;`, "a.wtf": "int x = 1;"},
+			"read-only", "main.wtf",
+		},
+		{
+			"reassign_module",
+			map[string]string{"main.wtf": `import "a.wtf"; a = 2;`, "a.wtf": "int x = 1;"},
+			"constant", "main.wtf",
+		},
+		{
+			"name_clash",
+			map[string]string{"main.wtf": `int a = 1; import "a.wtf";`, "a.wtf": "int x = 1;"},
+			"already declared", "main.wtf",
+		},
+		{
+			"invalid_default_name",
+			map[string]string{"main.wtf": `import "my-lib.wtf";`, "my-lib.wtf": "int x = 1;"},
+			"as name", "main.wtf",
+		},
+		{
+			"runtime_error_in_module",
+			map[string]string{"main.wtf": `import "a.wtf";`, "a.wtf": "int x = 1;\nint y = x / 0;"},
+			"division by zero", "a.wtf",
+		},
+		{
+			"error_in_module_function",
+			map[string]string{"main.wtf": `import "a.wtf"; a.f();`, "a.wtf": "func f() {\n  int y = 1 / 0;\n}"},
+			"division by zero", "a.wtf",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := writeModules(t, tt.files)
+
+			i := NewInterpreter(nil)
+			err := evalFile(t, i, filepath.Join(dir, "main.wtf"))
+			rtErr, ok := err.(*RuntimeError)
+			if !ok {
+				t.Fatalf("expected runtime error, got %v", err)
+			}
+			if !strings.Contains(rtErr.Msg, tt.contains) {
+				t.Errorf("expected %q in %q", tt.contains, rtErr.Msg)
+			}
+			if filepath.Base(rtErr.File) != tt.file {
+				t.Errorf("expected error in %s, got %s", tt.file, rtErr.File)
+			}
+		})
+	}
+}
+
+func TestInterpreter_ImportParseErrors(t *testing.T) {
+	dir := writeModules(t, map[string]string{
+		"main.wtf":   `import "broken.wtf";`,
+		"broken.wtf": "int x = 1;\nint = 2;",
+	})
+
+	i := NewInterpreter(nil)
+	err := evalFile(t, i, filepath.Join(dir, "main.wtf"))
+	if err == nil {
+		t.Fatal("expected an error, got none")
+	}
+	if !strings.Contains(err.Error(), "broken.wtf, Line 2") {
+		t.Errorf("expected the position in broken.wtf, got %q", err.Error())
+	}
+}
+
+// ============================================================================
+// Scoping Tests
+// ============================================================================
+
+func TestInterpreter_BlockScope(t *testing.T) {
+	input := `
+	int x = 1;
+	int seen = 0;
+	if (true) {
+		int inner = 5;
+		int x = 2;
+		seen = x;
+	}
+	`
+	i := NewInterpreter(nil)
+	i.Execute(input)
+
+	if _, ok := i.Variables["inner"]; ok {
+		t.Error("block variable 'inner' leaked into the global scope")
+	}
+
+	x := i.Variables["x"]
+	if val, ok := x.Value.(int64); !ok || val != 1 {
+		t.Errorf("shadowed x should keep its outer value 1, got %v", x.Value)
+	}
+
+	seen := i.Variables["seen"]
+	if val, ok := seen.Value.(int64); !ok || val != 2 {
+		t.Errorf("expected the inner x (2) to be visible inside the block, got %v", seen.Value)
+	}
+}
+
+func TestInterpreter_AssignmentUpdatesEnclosingScope(t *testing.T) {
+	input := `
+	int total = 0;
+	while (total < 3) {
+		if (true) {
+			total = total + 1;
+		}
+	}
+	`
+	i := NewInterpreter(nil)
+	i.Execute(input)
+
+	v := i.Variables["total"]
+	if val, ok := v.Value.(int64); !ok || val != 3 {
+		t.Errorf("expected 3, got %v", v.Value)
+	}
+}
+
+func TestInterpreter_LoopVariablesDoNotLeak(t *testing.T) {
+	input := `
+	for (int i = 0; i < 3; i = i + 1) { }
+	for j in 1..3 { }
+	`
+	i := NewInterpreter(nil)
+	i.Execute(input)
+
+	for _, name := range []string{"i", "j"} {
+		if _, ok := i.Variables[name]; ok {
+			t.Errorf("loop variable '%s' leaked into the global scope", name)
+		}
+	}
+}
+
+func TestInterpreter_ClosureCapturesLoopIteration(t *testing.T) {
+	input := `
+	func captured = func() int { return 0; };
+	for n in 1..3 {
+		if (n == 2) {
+			captured = func() int { return n; };
+		}
+	}
+	int result = captured();
+	`
+	i := NewInterpreter(nil)
+	i.Execute(input)
+
+	v := i.Variables["result"]
+	if val, ok := v.Value.(int64); !ok || val != 2 {
+		t.Errorf("expected 2, got %v", v.Value)
+	}
+}
+
+func TestInterpreter_ScopeErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+	}{
+		{"redeclared_global", "int x = 1; int x = 2;"},
+		{"redeclared_in_block", "if (true) { int y; string y; }"},
+		{"redeclared_parameter", "func f(int a) { int a = 1; } f(1);"},
+		{"out_of_scope_read", "if (true) { int z = 1; } print(z);"},
+		{"out_of_scope_assign", "if (true) { int z = 1; } z = 2;"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := NewLexer("test", tt.input)
+			p := NewParser(l)
+			program := p.ParseProgram()
+			checkParserErrors(t, p)
+
+			i := NewInterpreter(nil)
+			if _, err := i.Evaluate(program); err == nil {
+				t.Error("expected runtime error, got none")
+			}
+		})
+	}
+}
+
+// ============================================================================
+// Uint Type Tests
+// ============================================================================
+
+func TestInterpreter_UintBasic(t *testing.T) {
+	input := "uint x = 100;"
+	i := NewInterpreter(nil)
+	i.Execute(input)
+
+	v, ok := i.Variables["x"]
+	if !ok {
+		t.Fatal("variable 'x' not found")
+	}
+
+	if v.Type != types.Uint {
+		t.Errorf("expected type Uint, got %v", v.Type)
+	}
+
+	val, ok := v.Value.(uint64)
+	if !ok || val != 100 {
+		t.Errorf("expected 100, got %v", v.Value)
+	}
+}
+
+func TestInterpreter_UintRange(t *testing.T) {
+	input := `
+	uint(10, 20)[50] small;
+	uint(0xFFFF_FFFF_FFFF_FF00u, 0xFFFF_FFFF_FFFF_FFFFu)[50] huge;
+	`
+	i := NewInterpreter(nil)
+	i.Execute(input)
+
+	small, ok := i.Variables["small"]
+	if !ok {
+		t.Fatal("variable 'small' not found")
+	}
+	for _, elem := range small.Value.(*types.ArrayValue).Elements {
+		if val, ok := elem.(uint64); !ok || val < 10 || val >= 20 {
+			t.Errorf("expected a uint in [10, 20), got %v (%T)", elem, elem)
+		}
+	}
+
+	// Bounds above the int64 range are not truncated
+	huge, ok := i.Variables["huge"]
+	if !ok {
+		t.Fatal("variable 'huge' not found")
+	}
+	for _, elem := range huge.Value.(*types.ArrayValue).Elements {
+		if val, ok := elem.(uint64); !ok || val < 0xFFFF_FFFF_FFFF_FF00 {
+			t.Errorf("expected a uint of at least 0xFFFFFFFFFFFFFF00, got %v (%T)", elem, elem)
+		}
+	}
+}
+
+func TestInterpreter_UintArithmetic(t *testing.T) {
+	input := `
+	uint x = 10;
+	uint y = 5;
+	uint result = x + y;
+	`
+	i := NewInterpreter(nil)
+	i.Execute(input)
+
+	v, ok := i.Variables["result"]
+	if !ok {
+		t.Fatal("variable 'result' not found")
+	}
+
+	val, ok := v.Value.(uint64)
+	if !ok || val != 15 {
+		t.Errorf("expected 15, got %v", v.Value)
+	}
+}
+
+// ============================================================================
+// Unofloat Type Tests
+// ============================================================================
+
+func TestInterpreter_UnofloatBasic(t *testing.T) {
+	input := "unofloat x = 0.5;"
+	i := NewInterpreter(nil)
+	i.Execute(input)
+
+	v, ok := i.Variables["x"]
+	if !ok {
+		t.Fatal("variable 'x' not found")
+	}
+
+	if v.Type != types.Unofloat {
+		t.Errorf("expected type UnitFloat, got %v", v.Type)
+	}
+
+	val, ok := v.Value.(types.UnofloatType)
+	if !ok {
+		t.Fatalf("value is not Unofloat: %T", v.Value)
+	}
+
+	if float64(val) != 0.5 {
+		t.Errorf("expected 0.5, got %v", float64(val))
+	}
+}
+
+func TestInterpreter_UnofloatRange(t *testing.T) {
+	input := "unofloat(0.25, 0.5)[50] xs;"
+	i := NewInterpreter(nil)
+	i.Execute(input)
+
+	v, ok := i.Variables["xs"]
+	if !ok {
+		t.Fatal("variable 'xs' not found")
+	}
+	for _, elem := range v.Value.(*types.ArrayValue).Elements {
+		if val, ok := elem.(types.UnofloatType); !ok || val < 0.25 || val >= 0.5 {
+			t.Errorf("expected a unofloat in [0.25, 0.5), got %v (%T)", elem, elem)
+		}
+	}
+}
+
+func TestInterpreter_UnofloatClamping(t *testing.T) {
+	// Test that unofloat clamps values to [0, 1] for computed expressions
+	input := `
+	unofloat x = 0.8;
+	unofloat y = 0.5;
+	unofloat result = x + y;
+	`
+	i := NewInterpreter(nil)
+	i.Execute(input)
+
+	v, ok := i.Variables["result"]
+	if !ok {
+		t.Fatal("variable 'result' not found")
+	}
+
+	val, ok := v.Value.(types.UnofloatType)
+	if !ok {
+		t.Fatalf("value is not Unofloat: %v", v.Value)
+	}
+
+	fval := float64(val)
+	// 0.8 + 0.5 = 1.3, should be clamped to 1.0
+	if fval != 1.0 {
+		t.Errorf("expected 1.0 (clamped), got %v", fval)
+	}
+}
+
+func TestInterpreter_UnofloatArithmetic(t *testing.T) {
+	input := `
+	unofloat x = 0.6;
+	unofloat y = 0.2;
+	unofloat sum = x + y;
+	unofloat diff = x - y;
+	unofloat prod = x * y;
+	unofloat quot = x / y;
+	`
+	i := NewInterpreter(nil)
+	i.Execute(input)
+
+	tests := []struct {
+		name     string
+		expected float64
+	}{
+		{"sum", 0.8},
+		{"diff", 0.4},
+		{"prod", 0.12},
+		{"quot", 1.0}, // 0.6 / 0.2 = 3.0, clamped to 1.0
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v, ok := i.Variables[tt.name]
+			if !ok {
+				t.Fatalf("variable '%s' not found", tt.name)
+			}
+
+			val, ok := v.Value.(types.UnofloatType)
+			if !ok {
+				t.Fatalf("value is not Unofloat: %T", v.Value)
+			}
+
+			fval := float64(val)
+			// Allow small floating point differences
+			if fval < tt.expected-0.01 || fval > tt.expected+0.01 {
+				t.Errorf("expected ~%v, got %v", tt.expected, fval)
+			}
+		})
+	}
+}
+
+// ============================================================================
+// Ranged String and Bool Tests
+// ============================================================================
+
+func TestInterpreter_StringLengthRange(t *testing.T) {
+	input := "string(3, 6)[50] words;"
+	i := NewInterpreter(nil)
+	i.Execute(input)
+
+	v, ok := i.Variables["words"]
+	if !ok {
+		t.Fatal("variable 'words' not found")
+	}
+	for _, elem := range v.Value.(*types.ArrayValue).Elements {
+		if word, ok := elem.(string); !ok || len(word) < 3 || len(word) >= 6 {
+			t.Errorf("expected a string of 3 to 5 characters, got %q", elem)
+		}
+	}
+}
+
+func TestInterpreter_BoolProbability(t *testing.T) {
+	input := `
+	bool(1.0) always;
+	bool(0%) never;
+	bool(0.5)[1000] coins;
+	`
+	i := NewInterpreter(nil)
+	i.Execute(input)
+
+	if v, ok := i.Variables["always"]; !ok || v.Value != true {
+		t.Errorf("expected bool(1.0) to be true, got %v", v.Value)
+	}
+	if v, ok := i.Variables["never"]; !ok || v.Value != false {
+		t.Errorf("expected bool(0%%) to be false, got %v", v.Value)
+	}
+
+	heads := 0
+	for _, elem := range i.Variables["coins"].Value.(*types.ArrayValue).Elements {
+		if elem == true {
+			heads++
+		}
+	}
+	if heads < 400 || heads > 600 {
+		t.Errorf("expected about 500 of 1000 coins to be true, got %d", heads)
+	}
+}
+
+func TestInterpreter_InvalidRangeDeclarations(t *testing.T) {
+	tests := []struct {
+		input string
+		msg   string
+	}{
+		{"uint(-1, 5) x;", "uint range cannot have negative bounds"},
+		{"uint(5u, 5u) x;", "min is equal to max"},
+		{"unofloat(0.5, 2) x;", "unofloat range must lie within 0 and 1"},
+		{"unofloat(0.8, 0.2) x;", "min is greater than max"},
+		{"string(-1, 3) x;", "string length must be between 0 and 1000000"},
+		{"string(1.5, 3) x;", "string range takes the min and max length as ints"},
+		{"bool(2) x;", "bool probability must be between 0 and 1"},
+		{`bool("often") x;`, "bool probability must be a number"},
+		{"bool(0.1, 0.2) x;", "bool range takes a single probability, e.g. bool(0.3)"},
+		{"int(5) x;", "int range needs a min and a max"},
+		{"map(3)[string]int m;", "map size range needs a min and a max"},
+		{`float("a", 2) x;`, "invalid types for float range"},
+		{"struct P { int x; } P(1, 2) p;", "struct type P does not take a range"},
+		{`int(1, 5, "abc") x;`, "only string ranges take a charset"},
+		{`string(1, 5, "") x;`, "charset must be a non-empty string"},
+		{`type Code = string(2, 3, 7); Code c;`, "charset must be a non-empty string"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			l := NewLexer("test", tt.input)
+			p := NewParser(l)
+			program := p.ParseProgram()
+			checkParserErrors(t, p)
+
+			i := NewInterpreter(nil)
+			_, err := i.Evaluate(program)
+			rErr, ok := err.(*RuntimeError)
+			if !ok {
+				t.Fatalf("expected a runtime error, got %v", err)
+			}
+			if rErr.Kind != ErrorKindInvalidRange || rErr.Msg != tt.msg {
+				t.Errorf("expected %s %q, got %s %q", ErrorKindInvalidRange, tt.msg, rErr.Kind, rErr.Msg)
+			}
+		})
+	}
+}
+
+// ============================================================================
+// Named Type Tests
+// ============================================================================
+
+func TestInterpreter_NamedTypes(t *testing.T) {
+	input := `
+	type Dice = int(1, 7);
+	type Hex = string(4, 5, "0123456789abcdef");
+	type Coin = bool(1.0);
+	Dice[100] rolls;
+	Dice fixed = 3;
+	Hex id;
+	Coin heads;
+	func roll(Dice face) Dice { return face; }
+	int defaulted = roll();
+	int passed = roll(10);
+	struct Player { Dice luck; }
+	Player p;
+	`
+	i := NewInterpreter(nil)
+	i.Execute(input)
+
+	for _, elem := range i.Variables["rolls"].Value.(*types.ArrayValue).Elements {
+		if val, ok := elem.(int64); !ok || val < 1 || val > 6 {
+			t.Errorf("expected a roll from 1 to 6, got %v (%T)", elem, elem)
+		}
+	}
+
+	id, _ := i.Variables["id"].Value.(string)
+	if len(id) != 4 || strings.Trim(id, "0123456789abcdef") != "" {
+		t.Errorf("expected 4 hex digits, got %q", id)
+	}
+
+	field, _ := i.Variables["p"].Value.(*types.StructValue).Get("luck")
+	luck, _ := field.(int64)
+	expected := map[string]any{
+		"fixed":     int64(3),
+		"heads":     true,
+		"passed":    int64(10),
+		"defaulted": i.Variables["defaulted"].Value,
+	}
+	for name, want := range expected {
+		if v := i.Variables[name]; v.Value != want {
+			t.Errorf("%s: expected %v, got %v", name, want, v.Value)
+		}
+	}
+	if d := i.Variables["defaulted"].Value.(int64); d < 1 || d > 6 {
+		t.Errorf("expected a default roll from 1 to 6, got %d", d)
+	}
+	if luck < 1 || luck > 6 {
+		t.Errorf("expected the luck field from 1 to 6, got %d", luck)
+	}
+}
+
+func TestInterpreter_NamedTypeBoundsAreFixed(t *testing.T) {
+	// The bounds are evaluated once, where the type is declared
+	input := `
+	int n = 3;
+	type Small = int(0, n);
+	type Noisy = float ~ normal(n, 0);
+	n = 1000000;
+	Small[50] xs;
+	float noise;
+	func draw() int {
+		int n = 2000000;
+		Small s;
+		return s;
+	}
+	int inFunction = draw();
+	Noisy sample;
+	noise = sample;
+	`
+	i := NewInterpreter(nil)
+	i.Execute(input)
+
+	for _, elem := range i.Variables["xs"].Value.(*types.ArrayValue).Elements {
+		if val := elem.(int64); val < 0 || val >= 3 {
+			t.Errorf("expected a value from 0 to 2, got %d", val)
+		}
+	}
+	if val := i.Variables["inFunction"].Value.(int64); val < 0 || val >= 3 {
+		t.Errorf("expected a value from 0 to 2 inside a function, got %d", val)
+	}
+	if noise := i.Variables["noise"].Value; noise != 3.0 {
+		t.Errorf("expected the distribution to keep mean 3, got %v", noise)
+	}
+}
+
+func TestInterpreter_TypeofNamedType(t *testing.T) {
+	input := `
+	type Dice = int(1, 7);
+	Dice d;
+	Dice[2] pair;
+	string single = typeof(d);
+	string many = typeof(pair);
+	string sum = typeof(d + 1);
+	func show(Dice face) string { return typeof(face); }
+	string param = show(2);
+	`
+	i := NewInterpreter(nil)
+	i.Execute(input)
+
+	expected := map[string]string{
+		"single": "Dice",
+		"many":   "Dice[]",
+		"sum":    "int",
+		"param":  "Dice",
+	}
+	for name, want := range expected {
+		if v := i.Variables[name]; v.Value != want {
+			t.Errorf("%s: expected %q, got %v", name, want, v.Value)
+		}
+	}
+}
+
+// ============================================================================
+// Enum Tests
+// ============================================================================
+
+func TestInterpreter_EnumMembers(t *testing.T) {
+	input := `
+	enum Color { Red, Green, Blue }
+	Color c = Color.Green;
+	Color[50] palette;
+	bool same = c == Color.Green;
+	bool different = c != Color.Blue;
+	string name = "${c}";
+	string kind = typeof(c);
+	string kinds = typeof(palette);
+	func warmer(Color from) Color { return Color.Red; }
+	Color warm = warmer(c);
+	string matched = "none";
+	match c {
+		Color.Red => { matched = "red"; },
+		Color.Green => { matched = "green"; },
+		_ => { matched = "other"; }
+	}
+	struct Pixel { Color color; }
+	Pixel px;
+	`
+	i := NewInterpreter(nil)
+	i.Execute(input)
+
+	expected := map[string]any{
+		"c":         types.EnumValue{TypeName: "Color", Member: "Green"},
+		"same":      true,
+		"different": true,
+		"name":      "Green",
+		"kind":      "Color",
+		"kinds":     "Color[]",
+		"warm":      types.EnumValue{TypeName: "Color", Member: "Red"},
+		"matched":   "green",
+	}
+	for name, want := range expected {
+		if v := i.Variables[name]; v.Value != want {
+			t.Errorf("%s: expected %v, got %v", name, want, v.Value)
+		}
+	}
+
+	for _, elem := range i.Variables["palette"].Value.(*types.ArrayValue).Elements {
+		if member, ok := elem.(types.EnumValue); !ok || member.TypeName != "Color" {
+			t.Errorf("expected a Color, got %v (%T)", elem, elem)
+		}
+	}
+	if field, _ := i.Variables["px"].Value.(*types.StructValue).Get("color"); getTypeString(field) != "Color" {
+		t.Errorf("expected the color field to be a Color, got %v", field)
+	}
+}
+
+func TestInterpreter_EnumSelection(t *testing.T) {
+	input := `
+	enum Color { Red, Green, Blue }
+	enum Status { Ok: 90, Error: 10 }
+	Color[3000] colors;
+	Status[3000] statuses;
+	`
+	i := NewInterpreter(nil)
+	i.Rand.Seed(1)
+	i.Execute(input)
+
+	count := func(name string) map[string]int {
+		counts := map[string]int{}
+		for _, elem := range i.Variables[name].Value.(*types.ArrayValue).Elements {
+			counts[elem.(types.EnumValue).Member]++
+		}
+		return counts
+	}
+
+	// Uniform members are picked about 1000 times each
+	for member, n := range count("colors") {
+		if n < 850 || n > 1150 {
+			t.Errorf("expected about 1000 %s, got %d", member, n)
+		}
+	}
+
+	// Weighted members are picked about 2700 and 300 times
+	statuses := count("statuses")
+	if statuses["Ok"] < 2550 || statuses["Error"] < 150 || statuses["Error"] > 450 {
+		t.Errorf("expected about 2700 Ok and 300 Error, got %v", statuses)
+	}
+}
+
+func TestInterpreter_InvalidEnumUsage(t *testing.T) {
+	tests := []struct {
+		input string
+		kind  ErrorKind
+		msg   string
+	}{
+		{"enum Color { Red } Color c = 1;", ErrorKindTypeMismatch, "type mismatch: expected Color, got int"},
+		{"enum Color { Red } enum Size { Big } Color c; c = Size.Big;", ErrorKindTypeMismatch, "type mismatch: expected Color, got Size"},
+		{"enum Color { Red } Color c; bool b = c == 1;", ErrorKindTypeMismatch, "type mismatch: cannot compare Color with int"},
+		{"enum Color { Red } Color c; bool b = c < Color.Red;", ErrorKindRuntime, "operator < not supported for enum Color"},
+		{"enum Color { Red } Color c = Color.Purple;", ErrorKindUndefined, "enum Color has no member Purple"},
+		{`enum Status { Ok: 1, Error: "often" }`, ErrorKindRuntime, "enum weight must be a number, got string"},
+		{"enum Status { Ok: 1, Error: 0 }", ErrorKindRuntime, "enum weight must be positive, got 0"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			l := NewLexer("test", tt.input)
+			p := NewParser(l)
+			program := p.ParseProgram()
+			checkParserErrors(t, p)
+
+			i := NewInterpreter(nil)
+			_, err := i.Evaluate(program)
+			rErr, ok := err.(*RuntimeError)
+			if !ok {
+				t.Fatalf("expected a runtime error, got %v", err)
+			}
+			if rErr.Kind != tt.kind || rErr.Msg != tt.msg {
+				t.Errorf("expected %s %q, got %s %q", tt.kind, tt.msg, rErr.Kind, rErr.Msg)
+			}
+		})
+	}
+}
+
+// ============================================================================
+// Reroll Tests
+// ============================================================================
+
+func TestInterpreter_Reroll(t *testing.T) {
+	input := `
+	int(1, 7) d;
+	int(1, 7)[4] dice;
+	enum Coin { Heads, Tails }
+	Coin coin;
+	int n = 2;
+	int(0, n) bit;
+	n = 1000;
+	int[6] seen = [0, 0, 0, 0, 0, 0];
+	int maxBit = 0;
+	repeat 600 {
+		reroll d, bit;
+		seen[d - 1] = seen[d - 1] + 1;
+		if (bit > maxBit) { maxBit = bit; }
+	}
+	reroll dice, coin;
+	`
+	i := NewInterpreter(nil)
+	i.Execute(input)
+
+	// Every face comes up after 600 rerolls, and only faces from 1 to 6
+	for face, count := range i.Variables["seen"].Value.(*types.ArrayValue).Elements {
+		if count == int64(0) {
+			t.Errorf("expected face %d to come up", face+1)
+		}
+	}
+
+	// The range is evaluated once when declared, so changing n does not widen it
+	if maxBit := i.Variables["maxBit"].Value; maxBit != int64(1) {
+		t.Errorf("expected bit to stay within int(0, 2), got a max of %v", maxBit)
+	}
+
+	dice := i.Variables["dice"].Value.(*types.ArrayValue)
+	if len(dice.Elements) != 4 || dice.ElemType != types.Int {
+		t.Errorf("expected a fresh int[4], got %s %v", dice.ElemType, dice)
+	}
+	if coin := i.Variables["coin"].Value; getTypeString(coin) != "Coin" {
+		t.Errorf("expected a Coin, got %v", coin)
+	}
+}
+
+func TestInterpreter_RerollAll(t *testing.T) {
+	input := `
+	int(1, 1000000) outer;
+	int count = 0;
+	const int(1, 1000000) fixed;
+	int firstOuter = outer;
+	int firstFixed = fixed;
+	func roll(int(1, 1000000) face) int {
+		int before = face;
+		reroll all;
+		return face - before;
+	}
+	int changed = roll();
+	if (true) {
+		int(1, 1000000) inner;
+		int outer = 5;
+		reroll all;
+	}
+	`
+	i := NewInterpreter(nil)
+	i.Execute(input)
+
+	vars := i.Variables
+	if vars["count"].Value != int64(0) {
+		t.Errorf("expected count to keep its value, got %v", vars["count"].Value)
+	}
+	if vars["fixed"].Value != vars["firstFixed"].Value {
+		t.Errorf("expected the constant to keep its value, got %v and %v", vars["fixed"].Value, vars["firstFixed"].Value)
+	}
+	if vars["outer"].Value == vars["firstOuter"].Value {
+		t.Errorf("expected outer to be rerolled, it kept %v", vars["outer"].Value)
+	}
+	if vars["changed"].Value == int64(0) {
+		t.Error("expected the omitted parameter to be rerolled")
+	}
+}
+
+func TestInterpreter_InvalidReroll(t *testing.T) {
+	tests := []struct {
+		input string
+		kind  ErrorKind
+		msg   string
+	}{
+		{"int x = 5; reroll x;", ErrorKindRuntime, "cannot reroll x: it was not drawn at random when it was declared"},
+		{"func f(int(1, 7) face) { reroll face; } f(3);", ErrorKindRuntime, "cannot reroll face: it was not drawn at random when it was declared"},
+		{"const int(1, 7) d; reroll d;", ErrorKindConstAssignment, "cannot reroll constant: d"},
+		{"reroll missing;", ErrorKindUndefined, "variable not defined: missing"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			l := NewLexer("test", tt.input)
+			p := NewParser(l)
+			program := p.ParseProgram()
+			checkParserErrors(t, p)
+
+			i := NewInterpreter(nil)
+			_, err := i.Evaluate(program)
+			rErr, ok := err.(*RuntimeError)
+			if !ok {
+				t.Fatalf("expected a runtime error, got %v", err)
+			}
+			if rErr.Kind != tt.kind || rErr.Msg != tt.msg {
+				t.Errorf("expected %s %q, got %s %q", tt.kind, tt.msg, rErr.Kind, rErr.Msg)
+			}
+		})
+	}
+}
+
+// ============================================================================
+// Volatile Tests
+// ============================================================================
+
+func TestInterpreter_Volatile(t *testing.T) {
+	input := `
+	enum Coin { Heads, Tails }
+	volatile int(1, 1000000) die;
+	volatile Coin coin;
+	int first = die;
+	int second = die;
+	int third = die;
+	Coin flip = coin;
+	reroll all;
+	`
+	i := NewInterpreter(nil)
+	i.Execute(input)
+
+	vars := i.Variables
+	if !vars["die"].Volatile {
+		t.Error("expected die to be volatile")
+	}
+	first, second, third := vars["first"].Value, vars["second"].Value, vars["third"].Value
+	if first == second && second == third {
+		t.Errorf("expected a fresh draw on every read, got %v three times", first)
+	}
+	for _, v := range []any{first, second, third} {
+		if n := v.(int64); n < 1 || n >= 1000000 {
+			t.Errorf("expected a draw in [1, 1000000), got %d", n)
+		}
+	}
+	if flip := vars["flip"].Value; getTypeString(flip) != "Coin" {
+		t.Errorf("expected a Coin, got %v", flip)
+	}
+}
+
+func TestInterpreter_VolatileAssignment(t *testing.T) {
+	input := "volatile int(1, 7) die;\ndie = 42;\nint first = die;\nint second = die;"
+
+	l := NewLexer("test", input)
+	p := NewParser(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	// By default assigning is an error
+	_, err := NewInterpreter(nil).Evaluate(program)
+	rErr, ok := err.(*RuntimeError)
+	if !ok {
+		t.Fatalf("expected a runtime error, got %v", err)
+	}
+	if rErr.Kind != ErrorKindVolatileAssignment || rErr.Msg != "cannot assign to volatile variable: die" {
+		t.Errorf("unexpected error %s %q", rErr.Kind, rErr.Msg)
+	}
+	if rErr.Line != 2 || rErr.Column != 1 {
+		t.Errorf("expected error at 2:1, got %d:%d", rErr.Line, rErr.Column)
+	}
+
+	// With the pin mode the assigned value sticks
+	cfg := config.DefaultConfig
+	cfg.Volatile.Assignment = config.VolatileAssignPin
+	i := NewInterpreter(&cfg)
+	if _, err := i.Evaluate(program); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if i.Variables["die"].Volatile {
+		t.Error("expected die to stop being volatile once pinned")
+	}
+	if i.Variables["first"].Value != int64(42) || i.Variables["second"].Value != int64(42) {
+		t.Errorf("expected the pinned 42, got %v and %v", i.Variables["first"].Value, i.Variables["second"].Value)
+	}
+}
+
+// ============================================================================
+// Distribution Tests
+// ============================================================================
+
+func TestInterpreter_DistributionMeans(t *testing.T) {
+	input := `
+	seed(7);
+	float ~ normal(100, 15)[2000] iq;
+	int ~ poisson(3)[2000] arrivals;
+	float ~ exponential(0.5)[2000] wait;
+	int ~ binomial(10, 0.3)[2000] hits;
+	float ~ triangular(0, 3, 6)[2000] tri;
+	`
+	i := NewInterpreter(nil)
+	i.Execute(input)
+
+	tests := []struct {
+		name string
+		mean float64
+	}{
+		{"iq", 100},
+		{"arrivals", 3},
+		{"wait", 2},
+		{"hits", 3},
+		{"tri", 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v, ok := i.Variables[tt.name]
+			if !ok {
+				t.Fatalf("variable '%s' not found", tt.name)
+			}
+			sum := 0.0
+			elements := v.Value.(*types.ArrayValue).Elements
+			for _, elem := range elements {
+				f, _ := toFloat64(elem)
+				sum += f
+			}
+			if mean := sum / float64(len(elements)); math.Abs(mean-tt.mean) > tt.mean*0.1 {
+				t.Errorf("expected a mean of about %v, got %v", tt.mean, mean)
+			}
+		})
+	}
+}
+
+func TestInterpreter_DistributionWithRange(t *testing.T) {
+	input := `
+	int(0, 10) ~ normal(5, 20)[200] clipped;
+	uint ~ normal(0, 3)[200] positive;
+	unofloat ~ normal(0.5, 1)[200] unit;
+	`
+	i := NewInterpreter(nil)
+	i.Execute(input)
+
+	for _, elem := range i.Variables["clipped"].Value.(*types.ArrayValue).Elements {
+		if val, ok := elem.(int64); !ok || val < 0 || val >= 10 {
+			t.Errorf("expected an int in [0, 10), got %v (%T)", elem, elem)
+		}
+	}
+	for _, elem := range i.Variables["positive"].Value.(*types.ArrayValue).Elements {
+		if _, ok := elem.(uint64); !ok {
+			t.Errorf("expected a uint, got %v (%T)", elem, elem)
+		}
+	}
+	for _, elem := range i.Variables["unit"].Value.(*types.ArrayValue).Elements {
+		if val, ok := elem.(types.UnofloatType); !ok || val < 0 || val > 1 {
+			t.Errorf("expected a unofloat in [0, 1], got %v (%T)", elem, elem)
+		}
+	}
+}
+
+func TestInterpreter_InvalidDistributions(t *testing.T) {
+	tests := []struct {
+		input string
+		kind  ErrorKind
+		msg   string
+	}{
+		{"float ~ normal(0, -1) x;", ErrorKindInvalidValue, "invalid normal: stddev must not be negative"},
+		{"float ~ exponential(0) x;", ErrorKindInvalidValue, "invalid exponential: rate must be positive"},
+		{"int ~ binomial(2.5, 0.1) x;", ErrorKindInvalidValue, "invalid binomial: trials must be a whole number between 0 and 1000000"},
+		{"int ~ binomial(10, 2) x;", ErrorKindInvalidValue, "invalid binomial: p must be between 0 and 1"},
+		{"float ~ triangular(1, 7, 5) x;", ErrorKindInvalidValue, "invalid triangular: mode must lie between min and max"},
+		{`float ~ normal("a", 1) x;`, ErrorKindInvalidValue, "normal mean must be a finite number, got a"},
+		{"int(100, 200) ~ normal(0, 1) x;", ErrorKindInvalidRange, "no sample of normal(0, 1) fell within int(100, 200) after 1000 draws"},
+		{"uint ~ normal(-1000000, 1) x;", ErrorKindInvalidRange, "no sample of normal(-1000000, 1) fell within the uint type after 1000 draws"},
+		{"float(5, 1) ~ normal(0, 1) x;", ErrorKindInvalidRange, "min is greater than max"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			l := NewLexer("test", tt.input)
+			p := NewParser(l)
+			program := p.ParseProgram()
+			checkParserErrors(t, p)
+
+			i := NewInterpreter(nil)
+			_, err := i.Evaluate(program)
+			rErr, ok := err.(*RuntimeError)
+			if !ok {
+				t.Fatalf("expected a runtime error, got %v", err)
+			}
+			if rErr.Kind != tt.kind || rErr.Msg != tt.msg {
+				t.Errorf("expected %s %q, got %s %q", tt.kind, tt.msg, rErr.Kind, rErr.Msg)
+			}
+		})
+	}
+}
+
+// ============================================================================
+// String Operation Tests
+// ============================================================================
+
+func TestInterpreter_StringConcatenation(t *testing.T) {
+	input := `
+	string s1 = "Hello";
+	string s2 = " World";
+	string result = s1 + s2;
+	`
+	i := NewInterpreter(nil)
+	i.Execute(input)
+
+	v, ok := i.Variables["result"]
+	if !ok {
+		t.Fatal("variable 'result' not found")
+	}
+
+	val, ok := v.Value.(string)
+	if !ok || val != "Hello World" {
+		t.Errorf("expected 'Hello World', got %v", v.Value)
+	}
+}
+
+func TestInterpreter_StringComparison(t *testing.T) {
+	input := `
+	string s1 = "apple";
+	string s2 = "banana";
+	bool eq = s1 == s2;
+	bool neq = s1 != s2;
+	bool lt = s1 < s2;
+	`
+	i := NewInterpreter(nil)
+	i.Execute(input)
+
+	eqVal, _ := i.Variables["eq"]
+	if val, ok := eqVal.Value.(bool); !ok || val != false {
+		t.Errorf("expected eq=false, got %v", eqVal.Value)
+	}
+
+	neqVal, _ := i.Variables["neq"]
+	if val, ok := neqVal.Value.(bool); !ok || val != true {
+		t.Errorf("expected neq=true, got %v", neqVal.Value)
+	}
+
+	ltVal, _ := i.Variables["lt"]
+	if val, ok := ltVal.Value.(bool); !ok || val != true {
+		t.Errorf("expected lt=true, got %v", ltVal.Value)
+	}
+}
+
+// ============================================================================
+// Builtin Function Tests
+// ============================================================================
+
+func TestInterpreter_SeedFunction(t *testing.T) {
+	input := `
+	seed(42);
+	int(1, 100) x;
+	`
+
+	i1 := NewInterpreter(nil)
+	i1.Execute(input)
+	v1 := i1.Variables["x"]
 
 	i2 := NewInterpreter(nil)
 	i2.Execute(input)
@@ -709,6 +4033,27 @@ func TestInterpreter_TypeofFunction(t *testing.T) {
 	}
 }
 
+func TestInterpreter_LenFunction(t *testing.T) {
+	input := `
+	int[7] xs;
+	int n = len(xs);
+	int m = len("héllo");
+	string t = typeof(xs);
+	`
+	i := NewInterpreter(nil)
+	i.Execute(input)
+
+	if val := i.Variables["n"].Value; val != int64(7) {
+		t.Errorf("expected 7, got %v", val)
+	}
+	if val := i.Variables["m"].Value; val != int64(5) {
+		t.Errorf("expected 5, got %v", val)
+	}
+	if val := i.Variables["t"].Value; val != "int[]" {
+		t.Errorf("expected int[], got %v", val)
+	}
+}
+
 // ============================================================================
 // Type Coercion Tests
 // ============================================================================
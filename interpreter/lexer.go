@@ -1,6 +1,7 @@
 package interpreter
 
 import (
+	"fmt"
 	"strings"
 	"unicode"
 	"unicode/utf8"
@@ -20,6 +21,7 @@ type Lexer struct {
 	startLine   int // start line of current token
 	startColumn int // start column of current token
 	tokens      chan Token
+	prev        TokenType // type of the last emitted token
 	state       stateFn
 	errors      []*LexicalError
 }
@@ -29,14 +31,20 @@ type stateFn func(*Lexer) stateFn
 const EOS = -1 // end of shi
 
 func NewLexer(name, input string) *Lexer {
+	return newLexerAt(name, input, 1, 1)
+}
+
+// newLexerAt creates a lexer for source that starts at the given position of a
+// file, such as an expression embedded in a string with ${...}
+func newLexerAt(name, input string, line, column int) *Lexer {
 	l := &Lexer{
 		name:        name,
 		input:       input,
 		tokens:      make(chan Token),
-		line:        1,
-		column:      1,
-		startLine:   1,
-		startColumn: 1,
+		line:        line,
+		column:      column,
+		startLine:   line,
+		startColumn: column,
 		errors:      make([]*LexicalError, 0),
 	}
 	go l.run() // run the state machine concurrently
@@ -61,9 +69,11 @@ func (l *Lexer) NextToken() Token {
 
 // sends the token back to the client
 func (l *Lexer) emit(t TokenType) {
+	l.prev = t
 	l.tokens <- Token{
 		Type:    t,
 		Literal: l.input[l.start:l.pos],
+		File:    l.name,
 		Line:    l.startLine,
 		Column:  l.startColumn,
 	}
@@ -138,17 +148,25 @@ func (l *Lexer) acceptRun(valid string) {
 
 func (l *Lexer) errorf(formattedMsg string, args ...any) stateFn {
 	currentPos := &Position{
+		File:   l.name,
 		Line:   l.line,
 		Column: l.column,
 	}
-	err := NewLexicalError(currentPos, formattedMsg, args...)
+	return l.errorAt(currentPos, formattedMsg, args...)
+}
+
+// errorAt reports a lexical error at pos, which may lie before the current position
+func (l *Lexer) errorAt(pos *Position, formattedMsg string, args ...any) stateFn {
+	err := NewLexicalError(pos, formattedMsg, args...)
 	l.errors = append(l.errors, err)
+	l.prev = ILLEGAL
 
 	l.tokens <- Token{
 		Type:    ILLEGAL,
 		Literal: err.Msg,
-		Line:    l.line,
-		Column:  l.column,
+		File:    pos.File,
+		Line:    pos.Line,
+		Column:  pos.Column,
 	}
 	return lexStart
 }
@@ -171,8 +189,8 @@ func lexStart(l *Lexer) stateFn {
 		case ch == '+':
 			l.emit(PLUS)
 		case ch == '-':
-			// Could be minus or start of negative number
-			if isDigit(l.peek()) {
+			// Could be minus or start of negative number, a-5 is still a subtraction
+			if !l.afterOperand() && startsNumber(l.input[l.pos:]) {
 				l.backup()
 				return lexNumber
 			}
@@ -197,6 +215,9 @@ func lexStart(l *Lexer) stateFn {
 			if l.peek() == '=' {
 				l.next()
 				l.emit(EQ)
+			} else if l.peek() == '>' {
+				l.next()
+				l.emit(ARROW)
 			} else {
 				l.emit(ASSIGN)
 			}
@@ -225,6 +246,12 @@ func lexStart(l *Lexer) stateFn {
 			l.emit(SEMICOLON)
 		case ch == ',':
 			l.emit(COMMA)
+		case ch == ':':
+			l.emit(COLON)
+		case ch == '?':
+			l.emit(QUESTION)
+		case ch == '~':
+			l.emit(TILDE)
 		case ch == '(':
 			l.emit(LPAREN)
 		case ch == ')':
@@ -233,8 +260,25 @@ func lexStart(l *Lexer) stateFn {
 			l.emit(LBRACE)
 		case ch == '}':
 			l.emit(RBRACE)
+		case ch == '[':
+			l.emit(LBRACKET)
+		case ch == ']':
+			l.emit(RBRACKET)
+		case ch == '.':
+			if l.peek() == '.' {
+				l.next()
+				l.emit(DOTDOT)
+			} else if isDigit(l.peek()) && !l.afterOperand() {
+				// A float without a leading zero, such as .5
+				l.backup()
+				return lexNumber
+			} else {
+				l.emit(DOT)
+			}
 		case ch == '"':
 			return lexString
+		case ch == '`':
+			return lexRawString
 		case isDigit(ch):
 			l.backup()
 			return lexNumber
@@ -259,40 +303,264 @@ func lexComment(l *Lexer) stateFn {
 	}
 }
 
+// lexString lexes "..." and the multi-line """...""" strings. The first
+// invalid escape sequence turns the whole string into an ILLEGAL token, so the
+// error points at the escape without breaking up the rest of the line.
 func lexString(l *Lexer) stateFn {
+	triple := strings.HasPrefix(l.input[l.pos:], `""`)
+	if triple {
+		l.next()
+		l.next()
+	}
+
+	interpolated := false
+	var badEscape *LexicalError
 	for {
 		ch := l.next()
 		switch ch {
-		case EOS, '\n':
+		case EOS:
 			l.emit(STRING)
 			return l.errorf("unterminated string")
+		case '\n':
+			if !triple {
+				l.emit(STRING)
+				return l.errorf("unterminated string")
+			}
 		case '"':
+			if triple {
+				if !strings.HasPrefix(l.input[l.pos:], `""`) {
+					continue
+				}
+				// The last three quotes of a run close the string, so it may end with a quote
+				for l.peek() == '"' {
+					l.next()
+				}
+			}
+			if badEscape != nil {
+				l.ignore()
+				return l.errorAt(badEscape.Position, "%s", badEscape.Msg)
+			}
+			if interpolated {
+				l.emit(TEMPLATE)
+			} else {
+				l.emit(STRING)
+			}
+			return lexStart
+		case '\\':
+			pos := &Position{File: l.name, Line: l.line, Column: l.column - 1}
+			_, size, err := decodeEscape(l.input[l.pos:])
+			if err != nil {
+				if badEscape == nil {
+					badEscape = NewLexicalError(pos, "%s", err)
+				}
+				continue
+			}
+			for target := l.pos + size; l.pos < target; {
+				l.next()
+			}
+		case '$':
+			if l.peek() != '{' {
+				continue
+			}
+			l.next()
+			end := interpolationEnd(l.input[l.pos:])
+			if end < 0 {
+				l.emit(STRING)
+				return l.errorf("unterminated interpolation")
+			}
+			// Skip the embedded expression, the parser lexes it on its own
+			for target := l.pos + end + 1; l.pos < target; {
+				l.next()
+			}
+			interpolated = true
+		}
+	}
+}
+
+// lexRawString lexes `...` strings, which have no escapes or interpolations and may span lines
+func lexRawString(l *Lexer) stateFn {
+	for {
+		switch l.next() {
+		case EOS:
+			l.emit(STRING)
+			return l.errorf("unterminated raw string")
+		case '`':
 			l.emit(STRING)
 			return lexStart
+		}
+	}
+}
+
+// interpolationEnd returns the index of the '}' that closes an interpolation
+// in s, where s starts right after "${". Nested braces and string literals in
+// the expression are skipped, so "${m["}"]}" closes at the last brace.
+// It returns -1 when the interpolation is not closed on the same line.
+func interpolationEnd(s string) int {
+	depth := 0
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '\n':
+			return -1
+		case '{':
+			depth++
+		case '}':
+			if depth == 0 {
+				return i
+			}
+			depth--
+		case '"':
+			end := stringEnd(s[i+1:])
+			if end < 0 {
+				return -1
+			}
+			i += end + 1
+		case '`':
+			end := strings.IndexAny(s[i+1:], "`\n")
+			if end < 0 || s[i+1+end] != '`' {
+				return -1
+			}
+			i += end + 1
+		}
+	}
+	return -1
+}
+
+// stringEnd returns the index of the closing quote of a string literal in s,
+// where s starts right after the opening quote, or -1 if it is not closed
+func stringEnd(s string) int {
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '\n':
+			return -1
 		case '\\':
-			// Handle escape sequences if needed
-			if l.next() == EOS {
-				return l.errorf("unterminated string")
+			i++
+		case '"':
+			return i
+		case '$':
+			if i+1 < len(s) && s[i+1] == '{' {
+				end := interpolationEnd(s[i+2:])
+				if end < 0 {
+					return -1
+				}
+				i += end + 2
 			}
 		}
 	}
+	return -1
 }
 
+// lexNumber lexes decimal, hex (0xFF), binary (0b101) and octal (0o17)
+// integers, floats with an optional exponent (1.5e-3, .5) and the typed
+// literals 30% (unofloat) and 5u (uint). Digits may be grouped with
+// underscores, such as 1_000_000.
 func lexNumber(l *Lexer) stateFn {
 	l.accept("+-")
-	digits := "0123456789"
-	l.acceptRun(digits)
-
-	// Check for decimal point
-	if l.accept(".") {
+	digits := "0123456789_"
+	tokType := INT
+	prefixed := false
+
+	if strings.HasPrefix(l.input[l.pos:], "0") && len(l.input) > l.pos+1 && strings.ContainsRune("xXbBoO", rune(l.input[l.pos+1])) {
+		l.next()
+		switch l.next() {
+		case 'x', 'X':
+			digits = "0123456789abcdefABCDEF_"
+		case 'b', 'B':
+			digits = "01_"
+		case 'o', 'O':
+			digits = "01234567_"
+		}
+		prefixed = true
+		start := l.pos
 		l.acceptRun(digits)
-		l.emit(FLOAT)
+		if l.pos == start {
+			return l.numberError("missing digits after base prefix")
+		}
 	} else {
-		l.emit(INT)
+		l.acceptRun(digits)
+
+		// Check for decimal point, leaving a range operator (1..5) untouched
+		if !strings.HasPrefix(l.input[l.pos:], "..") && l.accept(".") {
+			l.acceptRun(digits)
+			tokType = FLOAT
+		}
+		if l.accept("eE") {
+			l.accept("+-")
+			if !isDigit(l.peek()) {
+				return l.numberError("missing exponent digits")
+			}
+			l.acceptRun(digits)
+			tokType = FLOAT
+		}
+	}
+
+	switch {
+	case l.accept("%"):
+		if prefixed {
+			return l.numberError("percent literal must be decimal")
+		}
+		tokType = PERCENT
+	case l.accept("u"):
+		if tokType == FLOAT {
+			return l.numberError("uint suffix on a float literal")
+		}
+		tokType = UINT
+	}
+
+	if ch := l.peek(); isAlphaNumeric(ch) {
+		return l.numberError("invalid digit %q", ch)
+	}
+	if !validSeparators(l.input[l.start:l.pos], strings.TrimSuffix(digits, "_")) {
+		return l.numberError("'_' must separate digits")
 	}
+
+	l.emit(tokType)
 	return lexStart
 }
 
+// numberError skips the rest of a malformed number and reports it at its start
+func (l *Lexer) numberError(formattedMsg string, args ...any) stateFn {
+	l.acceptRun(alphaNumerics + ".")
+	pos := &Position{File: l.name, Line: l.startLine, Column: l.startColumn}
+	lit := l.input[l.start:l.pos]
+	l.ignore()
+	return l.errorAt(pos, "invalid number %s: %s", lit, fmt.Sprintf(formattedMsg, args...))
+}
+
+// validSeparators reports whether every underscore in a number literal sits
+// between two digits, as in 1_000 or 0xFF_FF
+func validSeparators(lit, digits string) bool {
+	lit = strings.TrimLeft(lit, "+-")
+	if len(lit) > 2 && lit[0] == '0' && strings.ContainsRune("xXbBoO", rune(lit[1])) {
+		lit = lit[2:]
+	}
+	for i := 0; i < len(lit); i++ {
+		if lit[i] != '_' {
+			continue
+		}
+		if i == 0 || i == len(lit)-1 ||
+			!strings.ContainsRune(digits, rune(lit[i-1])) || !strings.ContainsRune(digits, rune(lit[i+1])) {
+			return false
+		}
+	}
+	return true
+}
+
+// afterOperand reports whether the last token ends an operand, in which case
+// a following - or . is an operator rather than the start of a number
+func (l *Lexer) afterOperand() bool {
+	switch l.prev {
+	case IDENT, INT, UINT, FLOAT, PERCENT, STRING, TEMPLATE, TRUE, FALSE, RPAREN, RBRACKET:
+		return true
+	}
+	return false
+}
+
+// startsNumber reports whether s begins with a digit or with a dot followed by a digit
+func startsNumber(s string) bool {
+	return len(s) > 0 && isDigit(rune(s[0])) ||
+		len(s) > 1 && s[0] == '.' && isDigit(rune(s[1]))
+}
+
 func lexIdentifier(l *Lexer) stateFn {
 	for {
 		ch := l.next()
@@ -309,6 +577,9 @@ func lexIdentifier(l *Lexer) stateFn {
 	return lexStart
 }
 
+// alphaNumerics is the set of ASCII letters, digits and underscores
+const alphaNumerics = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789_"
+
 func isSpace(ch rune) bool {
 	return ch == ' ' || ch == '\t' || ch == '\n' || ch == '\r'
 }
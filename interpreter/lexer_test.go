@@ -210,12 +210,13 @@ func TestLexer_ErrorHandling(t *testing.T) {
 // ============================================================================
 
 func TestLexer_AllOperators(t *testing.T) {
-	input := "+ - * / = == != < <= > >= && ||"
+	input := "+ - * / = == != < <= > >= && || => ? ~"
 	expected := []TokenType{
 		PLUS, MINUS, ASTERISK, SLASH,
 		ASSIGN, EQ, NEQ,
 		LT, LTE, GT, GTE,
 		AND, OR,
+		ARROW, QUESTION, TILDE,
 		EOF,
 	}
 
@@ -288,6 +289,7 @@ func TestLexer_AllTypeKeywords(t *testing.T) {
 		{"unofloat", TYPE_UNOFLOAT},
 		{"bool", TYPE_BOOL},
 		{"string", TYPE_STRING},
+		{"map", TYPE_MAP},
 	}
 
 	for _, tt := range tests {
@@ -313,6 +315,27 @@ func TestLexer_ControlFlowKeywords(t *testing.T) {
 		{"if", IF},
 		{"else", ELSE},
 		{"ifrand", IFRAND},
+		{"while", WHILE},
+		{"break", BREAK},
+		{"continue", CONTINUE},
+		{"for", FOR},
+		{"in", IN},
+		{"repeat", REPEAT},
+		{"repeatrand", REPEATRAND},
+		{"whilerand", WHILERAND},
+		{"const", CONST},
+		{"import", IMPORT},
+		{"as", AS},
+		{"match", MATCH},
+		{"choose", CHOOSE},
+		{"try", TRY},
+		{"catch", CATCH},
+		{"throw", THROW},
+		{"assert", ASSERT},
+		{"type", TYPE},
+		{"enum", ENUM},
+		{"reroll", REROLL},
+		{"volatile", VOLATILE},
 		{"true", TRUE},
 		{"false", FALSE},
 	}
@@ -333,9 +356,9 @@ func TestLexer_ControlFlowKeywords(t *testing.T) {
 // ============================================================================
 
 func TestLexer_Delimiters(t *testing.T) {
-	input := "( ) { } ; ,"
+	input := "( ) { } [ ] ; , : ."
 	expected := []TokenType{
-		LPAREN, RPAREN, LBRACE, RBRACE, SEMICOLON, COMMA, EOF,
+		LPAREN, RPAREN, LBRACE, RBRACE, LBRACKET, RBRACKET, SEMICOLON, COMMA, COLON, DOT, EOF,
 	}
 
 	lexer := NewLexer("test", input)
@@ -410,6 +433,10 @@ func TestLexer_NegativeNumbers(t *testing.T) {
 		{"negative_int", "-5", []TokenType{INT, EOF}},
 		{"negative_float", "-3.14", []TokenType{FLOAT, EOF}},
 		{"expression_with_neg", "x = -10", []TokenType{IDENT, ASSIGN, INT, EOF}},
+		{"subtraction", "a-5", []TokenType{IDENT, MINUS, INT, EOF}},
+		{"subtraction_after_paren", "(a)-5", []TokenType{LPAREN, IDENT, RPAREN, MINUS, INT, EOF}},
+		{"negative_argument", "f(1, -5)", []TokenType{IDENT, LPAREN, INT, COMMA, INT, RPAREN, EOF}},
+		{"negative_leading_dot", "-.5", []TokenType{FLOAT, EOF}},
 	}
 
 	for _, tt := range tests {
@@ -427,6 +454,74 @@ func TestLexer_NegativeNumbers(t *testing.T) {
 	}
 }
 
+func TestLexer_NumericLiterals(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected TokenType
+	}{
+		{"0xFF", INT},
+		{"0Xff_ff", INT},
+		{"0b1010", INT},
+		{"0o17", INT},
+		{"1_000_000", INT},
+		{"1e6", FLOAT},
+		{"1.5E-3", FLOAT},
+		{"2e+2", FLOAT},
+		{".5", FLOAT},
+		{"1_000.000_1", FLOAT},
+		{"30%", PERCENT},
+		{"12.5%", PERCENT},
+		{"5u", UINT},
+		{"0xFFu", UINT},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			lexer := NewLexer("test", tt.input)
+			tok := lexer.NextToken()
+			if tok.Type != tt.expected || tok.Literal != tt.input {
+				t.Errorf("expected %v %q, got %v %q", tt.expected, tt.input, tok.Type, tok.Literal)
+			}
+			if tok := lexer.NextToken(); tok.Type != EOF {
+				t.Errorf("expected EOF, got %v %q", tok.Type, tok.Literal)
+			}
+		})
+	}
+}
+
+func TestLexer_InvalidNumericLiterals(t *testing.T) {
+	tests := []struct {
+		input string
+		msg   string
+	}{
+		{"0x", "invalid number 0x: missing digits after base prefix"},
+		{"0b102", "invalid number 0b102: invalid digit '2'"},
+		{"12abc", "invalid number 12abc: invalid digit 'a'"},
+		{"1__0", "invalid number 1__0: '_' must separate digits"},
+		{"100_", "invalid number 100_: '_' must separate digits"},
+		{"1_.5", "invalid number 1_.5: '_' must separate digits"},
+		{"1e", "invalid number 1e: missing exponent digits"},
+		{"1.5u", "invalid number 1.5u: uint suffix on a float literal"},
+		{"0x10%", "invalid number 0x10%: percent literal must be decimal"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			lexer := NewLexer("test", "x = "+tt.input+";")
+			expected := []TokenType{IDENT, ASSIGN, ILLEGAL, SEMICOLON, EOF}
+			for idx, want := range expected {
+				tok := lexer.NextToken()
+				if tok.Type != want {
+					t.Fatalf("token %d: expected %v, got %v (literal: %s)", idx, want, tok.Type, tok.Literal)
+				}
+				if want == ILLEGAL && (tok.Literal != tt.msg || tok.Column != 5) {
+					t.Errorf("expected %q at column 5, got %q at column %d", tt.msg, tok.Literal, tok.Column)
+				}
+			}
+		})
+	}
+}
+
 // ============================================================================
 // Lexer Tests for String Literals
 // ============================================================================
@@ -470,6 +565,138 @@ func TestLexer_UnterminatedString(t *testing.T) {
 	}
 }
 
+func TestLexer_InvalidEscapes(t *testing.T) {
+	tests := []struct {
+		input  string
+		msg    string
+		column int
+	}{
+		{`x = "a\qb";`, `invalid escape sequence \q`, 7},
+		{`x = "ok \n then \x41";`, `invalid escape sequence \x`, 17},
+		{`x = "\u{110000}";`, `invalid unicode escape \u{110000}`, 6},
+		{`x = "\u{}";`, `invalid unicode escape \u{}: expected 1 to 6 hex digits`, 6},
+		{`x = "\u41";`, `invalid unicode escape, expected \u{...}`, 6},
+		{`x = """line \q""";`, `invalid escape sequence \q`, 13},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			lexer := NewLexer("test", tt.input)
+			var tokens []Token
+			for tok := lexer.NextToken(); tok.Type != EOF; tok = lexer.NextToken() {
+				tokens = append(tokens, tok)
+			}
+
+			// The whole string is replaced by one ILLEGAL token, the rest of the line lexes normally
+			expected := []TokenType{IDENT, ASSIGN, ILLEGAL, SEMICOLON}
+			if len(tokens) != len(expected) {
+				t.Fatalf("expected %d tokens, got %v", len(expected), tokens)
+			}
+			for idx, want := range expected {
+				if tokens[idx].Type != want {
+					t.Errorf("token %d: expected %v, got %v", idx, want, tokens[idx].Type)
+				}
+			}
+			if tok := tokens[2]; tok.Literal != tt.msg || tok.Column != tt.column {
+				t.Errorf("expected %q at column %d, got %q at column %d", tt.msg, tt.column, tok.Literal, tok.Column)
+			}
+			if len(lexer.Errors()) != 1 {
+				t.Errorf("expected 1 lexical error, got %d", len(lexer.Errors()))
+			}
+		})
+	}
+}
+
+func TestLexer_RawAndMultiLineStrings(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		literal string
+		line    int // line of the token after the string
+	}{
+		{"raw", "`C:\\new\\q ${x}`;", "`C:\\new\\q ${x}`", 1},
+		{"raw_multi_line", "`a\nb\nc`;", "`a\nb\nc`", 3},
+		{"triple", `"""say "hi" and ""bye""""";`, `"""say "hi" and ""bye"""""`, 1},
+		{"triple_multi_line", "\"\"\"\nline 1\nline 2\n\"\"\";", "\"\"\"\nline 1\nline 2\n\"\"\"", 4},
+		{"empty_triple", `"""""";`, `""""""`, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lexer := NewLexer("test", tt.input)
+			tok := lexer.NextToken()
+			if tok.Type != STRING || tok.Literal != tt.literal {
+				t.Errorf("expected STRING %s, got %v %s", tt.literal, tok.Type, tok.Literal)
+			}
+			next := lexer.NextToken()
+			if next.Type != SEMICOLON || next.Line != tt.line {
+				t.Errorf("expected SEMICOLON on line %d, got %v on line %d", tt.line, next.Type, next.Line)
+			}
+		})
+	}
+}
+
+func TestLexer_UnterminatedRawAndMultiLineStrings(t *testing.T) {
+	tests := []string{"`abc", `"""abc""`, "\"\"\"\nabc\n"}
+
+	for _, input := range tests {
+		t.Run(input, func(t *testing.T) {
+			lexer := NewLexer("test", input)
+			for tok := lexer.NextToken(); tok.Type != EOF; tok = lexer.NextToken() {
+			}
+			if len(lexer.Errors()) == 0 {
+				t.Error("expected a lexical error, got none")
+			}
+		})
+	}
+}
+
+func TestLexer_InterpolatedStrings(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected TokenType
+	}{
+		{"plain_dollar", `"costs $5"`, STRING},
+		{"escaped", `"\${x}"`, STRING},
+		{"simple", `"rolled ${dice}"`, TEMPLATE},
+		{"nested_braces", `"${ {"a": 1} }"`, TEMPLATE},
+		{"string_inside", `"${m["}"]}"`, TEMPLATE},
+		{"nested_template", `"a ${"b ${c}"} d"`, TEMPLATE},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lexer := NewLexer("test", tt.input+";")
+			tok := lexer.NextToken()
+			if tok.Type != tt.expected {
+				t.Errorf("expected %v, got %v", tt.expected, tok.Type)
+			}
+			if tok.Literal != tt.input {
+				t.Errorf("expected %s, got %s", tt.input, tok.Literal)
+			}
+			if next := lexer.NextToken(); next.Type != SEMICOLON {
+				t.Errorf("expected SEMICOLON after the string, got %v", next.Type)
+			}
+		})
+	}
+}
+
+func TestLexer_UnterminatedInterpolation(t *testing.T) {
+	tests := []string{`"${x"`, `"${x}`, "\"${x\n}\"", `"${"}"`}
+
+	for _, input := range tests {
+		t.Run(input, func(t *testing.T) {
+			lexer := NewLexer("test", input)
+			for tok := lexer.NextToken(); tok.Type != EOF; tok = lexer.NextToken() {
+			}
+			if len(lexer.Errors()) == 0 {
+				t.Error("expected a lexical error, got none")
+			}
+		})
+	}
+}
+
 // ============================================================================
 // Lexer Tests for Identifiers
 // ============================================================================
@@ -752,6 +979,33 @@ func TestLexer_FloatRangeSyntax(t *testing.T) {
 	}
 }
 
+func TestLexer_DotDotRange(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected []TokenType
+	}{
+		{"int_range", "0..10", []TokenType{INT, DOTDOT, INT, EOF}},
+		{"ident_range", "1..n", []TokenType{INT, DOTDOT, IDENT, EOF}},
+		{"for_in", "for i in 0..n {", []TokenType{FOR, IDENT, IN, INT, DOTDOT, IDENT, LBRACE, EOF}},
+		{"float_unaffected", "1.5", []TokenType{FLOAT, EOF}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lexer := NewLexer("test", tt.input)
+
+			for i, expectedType := range tt.expected {
+				tok := lexer.NextToken()
+				if tok.Type != expectedType {
+					t.Errorf("token[%d] - expected %v, got %v (literal: %q)",
+						i, expectedType, tok.Type, tok.Literal)
+				}
+			}
+		})
+	}
+}
+
 // ============================================================================
 // Lexer Tests for Complex Expressions
 // ============================================================================
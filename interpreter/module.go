@@ -0,0 +1,169 @@
+package interpreter
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"wtf-script/types"
+)
+
+// Module is the runtime value of an imported file. Its top-level variables and
+// functions are reached through the name it was imported as, e.g. dice.roll().
+type Module struct {
+	File string       // the file name used in diagnostics, relative to the working directory
+	path string       // absolute path, identifies the module
+	Env  *Environment // the top-level scope of the file
+}
+
+// typeRegistry holds the struct and enum types declared at the top level of a file.
+// Every module has its own, so a module only sees the types it declares itself.
+type typeRegistry struct {
+	module  string // path of the module, empty for the main program
+	structs map[string]*structType
+	enums   map[string]*enumType
+	named   map[string]*namedType
+}
+
+// newTypeRegistry creates the type registry of a module, see typeRegistry
+func (i *Interpreter) newTypeRegistry(module string) *typeRegistry {
+	reg := &typeRegistry{
+		module:  module,
+		structs: make(map[string]*structType),
+		enums:   make(map[string]*enumType),
+		named:   make(map[string]*namedType),
+	}
+	i.types[module] = reg
+	return reg
+}
+
+func newModule(file string, env *Environment) (*Module, error) {
+	path, err := filepath.Abs(file)
+	if err != nil {
+		return nil, err
+	}
+	return &Module{File: file, path: path, Env: env}, nil
+}
+
+// Lookup returns the value of a top-level variable or function of the module,
+// drawing a fresh value for a volatile variable
+func (m *Module) Lookup(name string) (any, bool) {
+	v, ok := m.Env.Get(name)
+	if !ok {
+		return nil, false
+	}
+	val, err := readVariable(v)
+	return val, err == nil
+}
+
+func (m *Module) String() string {
+	return "module " + m.File
+}
+
+func (i *Interpreter) evalImportStmt(node *ImportStmt) (any, error) {
+	pos := node.Token.Position()
+
+	path := node.Path.Value
+	if path == "" {
+		return nil, NewRuntimeError(pos, "invalid import path %s", node.Path.String())
+	}
+
+	name := moduleName(path)
+	if node.Alias != nil {
+		name = node.Alias.Value
+	} else if !isIdentifierName(name) {
+		return nil, NewRuntimeError(pos, "cannot derive a module name from %s, use import %s as name", path, node.Path.String())
+	}
+	if i.env.Has(name) {
+		return nil, NewRuntimeError(pos, "variable already declared in this scope: %s", name)
+	}
+
+	// Imports are resolved relative to the file containing the import statement
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(filepath.Dir(node.Token.File), path)
+	}
+
+	mod, err := i.loadModule(path, pos)
+	if err != nil {
+		return nil, err
+	}
+
+	i.env.Define(name, types.Variable{Type: types.Module, Value: mod, Const: true})
+	return mod, nil
+}
+
+// loadModule parses and evaluates a file in a top-level scope of its own.
+// Every file is evaluated once, later imports of the same file share the module.
+func (i *Interpreter) loadModule(file string, pos *Position) (*Module, error) {
+	mod, err := newModule(file, NewEnvironment(nil))
+	if err != nil {
+		return nil, NewRuntimeError(pos, "cannot import %s: %v", file, err)
+	}
+	if loaded, ok := i.modules[mod.path]; ok {
+		return loaded, nil
+	}
+
+	for idx, m := range i.importing {
+		if m.path == mod.path {
+			chain := []string{}
+			for _, m := range i.importing[idx:] {
+				chain = append(chain, m.File)
+			}
+			chain = append(chain, file)
+			return nil, NewRuntimeError(pos, "import cycle: %s", strings.Join(chain, " -> "))
+		}
+	}
+
+	content, err := os.ReadFile(file)
+	if err != nil {
+		var pathErr *fs.PathError
+		if errors.As(err, &pathErr) {
+			err = pathErr.Err
+		}
+		return nil, NewRuntimeError(pos, "cannot import %s: %v", file, err)
+	}
+
+	l := NewLexer(file, string(content))
+	p := NewParser(l)
+	program := p.ParseProgram()
+	for _, w := range p.warnings {
+		LogWarning("%s", w)
+	}
+	if len(p.errors) > 0 {
+		errs := make([]error, len(p.errors))
+		for idx, e := range p.errors {
+			errs[idx] = e
+		}
+		return nil, errors.Join(errs...)
+	}
+
+	mod.Env.types = i.newTypeRegistry(mod.path)
+	i.importing = append(i.importing, mod)
+	_, err = i.evalInScope(mod.Env, func() (any, error) {
+		return i.evalStatements(program.Statements)
+	})
+	i.importing = i.importing[:len(i.importing)-1]
+	if err != nil {
+		return nil, err
+	}
+
+	i.modules[mod.path] = mod
+	return mod, nil
+}
+
+// moduleName derives the default name of an import from its file name: "lib/dice.wtf" is dice
+func moduleName(path string) string {
+	base := filepath.Base(path)
+	return strings.TrimSuffix(base, filepath.Ext(base))
+}
+
+// isIdentifierName reports whether s would be lexed as an identifier rather than a keyword
+func isIdentifierName(s string) bool {
+	for idx, ch := range s {
+		if !isAlphaNumeric(ch) || (idx == 0 && isDigit(ch)) {
+			return false
+		}
+	}
+	return s != "" && LookupIdent(s) == IDENT
+}
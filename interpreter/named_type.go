@@ -0,0 +1,42 @@
+package interpreter
+
+// namedType is the runtime definition of a named type such as type Dice = int(1, 7);.
+// The parser copies the type into every declaration that uses it, with its bounds
+// replaced by NamedBound expressions that read the values evaluated here.
+type namedType struct {
+	Decl   *TypeDecl
+	values map[Expression]any // the value of every bound expression of the declaration
+}
+
+// evalTypeDecl evaluates the range, charset and distribution parameters of a named type
+// once, in the scope of the declaration
+func (i *Interpreter) evalTypeDecl(node *TypeDecl) (any, error) {
+	base := node.Base
+	exprs := []Expression{base.RangeMin, base.RangeMax, base.Charset}
+	if base.Distribution != nil {
+		exprs = append(exprs, base.Distribution.Args...)
+	}
+
+	def := &namedType{Decl: node, values: make(map[Expression]any)}
+	for _, expr := range exprs {
+		if expr == nil {
+			continue
+		}
+		val, err := i.Evaluate(expr)
+		if err != nil {
+			return nil, err
+		}
+		def.values[expr] = val
+	}
+	i.env.typeRegistry().named[node.Name.Value] = def
+	return nil, nil
+}
+
+func (i *Interpreter) evalNamedBound(node *NamedBound) (any, error) {
+	def, ok := i.env.typeRegistry().named[node.Type.Name.Value]
+	if !ok {
+		return nil, NewRuntimeError(node.Token.Position(),
+			"type %s is used before its declaration has run", node.Type.Name.Value)
+	}
+	return def.values[node.Expr], nil
+}
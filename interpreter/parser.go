@@ -2,36 +2,45 @@ package interpreter
 
 import (
 	"strconv"
+	"strings"
 )
 
 // Precedence levels
 const (
 	_ int = iota
 	LOWEST
+	TERNARY     // ? :
 	LOGICAL_OR  // ||
 	LOGICAL_AND // &&
 	EQUALS      // ==
 	LESSGREATER // > or <
+	RANGE       // ..
 	SUM         // +
 	PRODUCT     // *
 	PREFIX      // -X or !X
 	CALL        // myFunction(X)
+	INDEX       // array[index] or object.field
 )
 
 var precedences = map[TokenType]int{
+	QUESTION: TERNARY,
 	OR:       LOGICAL_OR,
 	AND:      LOGICAL_AND,
 	EQ:       EQUALS,
 	NEQ:      EQUALS,
 	LT:       LESSGREATER,
+	IN:       LESSGREATER,
 	LTE:      LESSGREATER,
 	GT:       LESSGREATER,
 	GTE:      LESSGREATER,
+	DOTDOT:   RANGE,
 	PLUS:     SUM,
 	MINUS:    SUM,
 	SLASH:    PRODUCT,
 	ASTERISK: PRODUCT,
 	LPAREN:   CALL,
+	LBRACKET: INDEX,
+	DOT:      INDEX,
 }
 
 type (
@@ -40,32 +49,58 @@ type (
 )
 
 type Parser struct {
-	l      *Lexer
-	errors []*ParserError
+	l        *Lexer
+	errors   []*ParserError
+	warnings []*ParserWarning
 
 	curToken  Token
 	peekToken Token
 
 	prefixParseFns map[TokenType]prefixParseFn
 	infixParseFns  map[TokenType]infixParseFn
+
+	// structNames holds the struct types declared so far, so that User u; parses as a declaration
+	structNames map[string]bool
+	// typeNames holds the named types declared so far, such as type Dice = int(1, 7);
+	typeNames map[string]*TypeDecl
+	// enumNames holds the enums declared so far, so that Color c; parses as a declaration of type ENUM
+	enumNames map[string]bool
+	// blockDepth counts the blocks being parsed; types can only be declared at the top level (depth 0)
+	blockDepth int
+	// fieldTypes holds the field types that were not declared yet when their struct was parsed,
+	// they must name a struct declared later in the file
+	fieldTypes []Token
 }
 
 func NewParser(l *Lexer) *Parser {
 	p := &Parser{
-		l:      l,
-		errors: make([]*ParserError, 0),
+		l:           l,
+		errors:      make([]*ParserError, 0),
+		structNames: make(map[string]bool),
+		typeNames:   make(map[string]*TypeDecl),
+		enumNames:   make(map[string]bool),
 	}
 
 	p.prefixParseFns = make(map[TokenType]prefixParseFn)
 	p.registerPrefix(IDENT, p.parseIdentifier)
 	p.registerPrefix(INT, p.parseIntegerLiteral)
 	p.registerPrefix(FLOAT, p.parseFloatLiteral)
+	p.registerPrefix(UINT, p.parseUintLiteral)
+	p.registerPrefix(PERCENT, p.parsePercentLiteral)
 	p.registerPrefix(TRUE, p.parseBoolean)
 	p.registerPrefix(FALSE, p.parseBoolean)
 	p.registerPrefix(STRING, p.parseStringLiteral)
+	p.registerPrefix(TEMPLATE, p.parseTemplateString)
+	p.registerPrefix(ILLEGAL, p.parseIllegal)
 	p.registerPrefix(MINUS, p.parsePrefixExpression)
 	p.registerPrefix(BANG, p.parsePrefixExpression)
 	p.registerPrefix(LPAREN, p.parseGroupedExpression)
+	p.registerPrefix(TYPE_INT, p.parseTypedRange)
+	p.registerPrefix(TYPE_UINT, p.parseTypedRange)
+	p.registerPrefix(FUNC, p.parseFunctionLiteral)
+	p.registerPrefix(LBRACKET, p.parseArrayLiteral)
+	p.registerPrefix(LBRACE, p.parseMapLiteral)
+	p.registerPrefix(IFRAND, p.parseIfrandExpression)
 
 	p.infixParseFns = make(map[TokenType]infixParseFn)
 	p.registerInfix(PLUS, p.parseInfixExpression)
@@ -80,7 +115,12 @@ func NewParser(l *Lexer) *Parser {
 	p.registerInfix(GTE, p.parseInfixExpression)
 	p.registerInfix(AND, p.parseInfixExpression)
 	p.registerInfix(OR, p.parseInfixExpression)
+	p.registerInfix(IN, p.parseInfixExpression)
 	p.registerInfix(LPAREN, p.parseCallExpression)
+	p.registerInfix(DOTDOT, p.parseRangeExpression)
+	p.registerInfix(LBRACKET, p.parseIndexExpression)
+	p.registerInfix(DOT, p.parseMemberExpression)
+	p.registerInfix(QUESTION, p.parseConditionalExpression)
 
 	// Read two tokens, so curToken and peekToken are both set
 	p.nextToken()
@@ -99,15 +139,13 @@ func (p *Parser) ParseProgram() *Program {
 	program.Statements = []Statement{}
 
 	for p.curToken.Type != EOF {
-		if p.curToken.Type == ILLEGAL {
-			p.errors = append(p.errors, NewIllegalTokenError(&p.curToken))
-		}
 		stmt := p.parseStatement()
 		if stmt != nil {
 			program.Statements = append(program.Statements, stmt)
 		}
 		p.nextToken()
 	}
+	p.checkFieldTypes()
 
 	return program
 }
@@ -120,6 +158,15 @@ func (p *Parser) Errors() []string {
 	return errs
 }
 
+// Warnings returns the warnings found while parsing, they do not prevent evaluation
+func (p *Parser) Warnings() []string {
+	var warnings []string
+	for _, w := range p.warnings {
+		warnings = append(warnings, w.String())
+	}
+	return warnings
+}
+
 func (p *Parser) peekError(t TokenType) {
 	p.errors = append(p.errors, NewExpectedTokenError(&p.peekToken, t))
 }
@@ -134,13 +181,65 @@ func (p *Parser) registerInfix(tokenType TokenType, fn infixParseFn) {
 
 func (p *Parser) parseStatement() Statement {
 	switch p.curToken.Type {
-	case TYPE_INT, TYPE_UINT, TYPE_FLOAT, TYPE_UNOFLOAT, TYPE_BOOL, TYPE_STRING:
+	case TYPE_INT, TYPE_UINT, TYPE_FLOAT, TYPE_UNOFLOAT, TYPE_BOOL, TYPE_STRING, TYPE_MAP:
 		return p.parseVarStatement()
 	case IF, IFRAND:
 		return p.parseIfStatement()
+	case WHILE, WHILERAND:
+		return p.parseWhileStatement()
+	case REPEAT, REPEATRAND:
+		return p.parseRepeatStatement()
+	case FUNC:
+		// func(...) { ... }(...) is an immediately invoked function literal
+		if p.peekToken.Type == LPAREN {
+			return p.parseExpressionStatement()
+		}
+		// func[] handlers = [...]; is an array declaration
+		if p.peekToken.Type == LBRACKET {
+			return p.parseVarStatement()
+		}
+		return p.parseFuncDeclaration()
+	case RETURN:
+		return p.parseReturnStatement()
+	case FOR:
+		return p.parseForStatement()
+	case STRUCT:
+		return p.parseStructDeclaration()
+	case TYPE:
+		return p.parseTypeDeclaration()
+	case ENUM:
+		return p.parseEnumDeclaration()
+	case CONST:
+		return p.parseConstStatement()
+	case VOLATILE:
+		return p.parseVolatileStatement()
+	case IMPORT:
+		return p.parseImportStatement()
+	case MATCH:
+		return p.parseMatchStatement()
+	case CHOOSE:
+		return p.parseChooseStatement()
+	case TRY:
+		return p.parseTryStatement()
+	case THROW:
+		return p.parseThrowStatement()
+	case ASSERT:
+		return p.parseAssertStatement()
+	case REROLL:
+		return p.parseRerollStatement()
+	case BREAK:
+		return p.parseBreakStatement()
+	case CONTINUE:
+		return p.parseContinueStatement()
 	case IDENT:
+		// A declared struct, type or enum name starts a declaration: User u; or Dice d;
+		// Color.Red on its own is an expression
+		if p.isTypeName(p.curToken) && p.peekToken.Type != DOT {
+			return p.parseVarStatement()
+		}
 		// Could be an assignment or an expression statement
-		// If peek is ASSIGN, it's an assignment
+		// If peek is ASSIGN, it's an assignment. Element assignments
+		// (xs[i] = value) and field assignments (u.age = 30) are detected by parseExpressionStatement.
 		if p.peekToken.Type == ASSIGN {
 			return p.parseAssignStatement()
 		}
@@ -151,47 +250,308 @@ func (p *Parser) parseStatement() Statement {
 }
 
 func (p *Parser) parseVarStatement() Statement {
-	stmt := &VarDecl{Token: p.curToken, Type: p.curToken.Type}
+	stmt := p.parseTypedName()
+	if stmt == nil {
+		return nil
+	}
+
+	// Optional assignment: = value
+	if p.peekToken.Type == ASSIGN {
+		p.nextToken() // consume name
+		p.nextToken() // consume '='
+		stmt.Value = p.parseExpression(LOWEST)
+	}
+
+	if p.peekToken.Type == SEMICOLON {
+		p.nextToken()
+	}
+
+	return stmt
+}
+
+// parseConstStatement parses a declaration prefixed with const: const float PI = 3.14;
+func (p *Parser) parseConstStatement() Statement {
+	p.nextToken() // consume const
+	if !p.isTypeName(p.curToken) {
+		p.errors = append(p.errors, NewParserError(
+			p.curToken.Position(),
+			"expected type after const, got %s", p.curToken.Type))
+		return nil
+	}
+
+	stmt, ok := p.parseVarStatement().(*VarDecl)
+	if !ok || stmt == nil {
+		return nil
+	}
+	stmt.Const = true
+	return stmt
+}
+
+// parseVolatileStatement parses a declaration prefixed with volatile: volatile int(1, 6) die;
+// Only single random values can be volatile, since the value is drawn again on every read.
+func (p *Parser) parseVolatileStatement() Statement {
+	tok := p.curToken
+	p.nextToken() // consume volatile
+	if !p.isTypeName(p.curToken) {
+		p.errors = append(p.errors, NewParserError(
+			p.curToken.Position(),
+			"expected type after volatile, got %s", p.curToken.Type))
+		return nil
+	}
+
+	stmt, ok := p.parseVarStatement().(*VarDecl)
+	if !ok || stmt == nil {
+		return nil
+	}
+	stmt.Volatile = true
+
+	switch {
+	case stmt.Value != nil:
+		p.errors = append(p.errors, NewParserError(tok.Position(),
+			"volatile variable %s is drawn on every read and cannot have a value", stmt.Name.Value))
+	case stmt.Array || stmt.KeyType != "" || stmt.Type == IDENT || stmt.Type == FUNC:
+		p.errors = append(p.errors, NewParserError(tok.Position(),
+			"volatile variable %s must be a single random value, not an array, map, struct or func", stmt.Name.Value))
+	}
+	return stmt
+}
+
+// parseTypedName parses a type with an optional range and array suffix followed by a name,
+// e.g. int x, int(1, 6) face, int(1, 6)[10] dice or map(1, 5)[string]int scores.
+// It is shared by declarations and function parameters.
+func (p *Parser) parseTypedName() *VarDecl {
+	decl := &VarDecl{Token: p.curToken, Type: p.curToken.Type}
+
+	if named, ok := p.typeNames[p.curToken.Literal]; ok && p.curToken.Type == IDENT {
+		// Dice d; takes the base type, range and distribution of type Dice = int(1, 7);
+		base := named.Base
+		decl.Type, decl.TypeName = base.Type, named.Name.Value
+		bind := func(expr Expression) Expression {
+			if expr == nil {
+				return nil
+			}
+			return &NamedBound{Token: p.curToken, Type: named, Expr: expr}
+		}
+		decl.RangeMin, decl.RangeMax, decl.Charset = bind(base.RangeMin), bind(base.RangeMax), bind(base.Charset)
+		if dist := base.Distribution; dist != nil {
+			decl.Distribution = &Distribution{Token: dist.Token, Name: dist.Name, Args: make([]Expression, len(dist.Args))}
+			for idx, arg := range dist.Args {
+				decl.Distribution.Args[idx] = bind(arg)
+			}
+		}
+		if p.peekToken.Type == LPAREN || p.peekToken.Type == TILDE {
+			p.errors = append(p.errors, NewParserError(p.peekToken.Position(),
+				"named type %s already has its range and distribution", named.Name.Value))
+			if !p.parseTypeRefinement(&VarDecl{Token: p.curToken, Type: base.Type}) {
+				return nil
+			}
+		}
+	} else if p.enumNames[p.curToken.Literal] && p.curToken.Type == IDENT {
+		// Color c; picks a member of enum Color, the enum alone decides how
+		decl.Type, decl.TypeName = ENUM, p.curToken.Literal
+		if p.peekToken.Type == LPAREN || p.peekToken.Type == TILDE {
+			p.errors = append(p.errors, NewParserError(p.peekToken.Position(),
+				"enum %s does not take a range or distribution", decl.TypeName))
+			if !p.parseTypeRefinement(&VarDecl{Token: p.curToken, Type: ENUM}) {
+				return nil
+			}
+		}
+	} else if !p.parseTypeRefinement(decl) {
+		return nil
+	}
+
+	// Maps always carry their key and value types: map[key]value name
+	if decl.Token.Type == TYPE_MAP {
+		key, value, ok := p.parseMapTypeSuffix()
+		if !ok {
+			return nil
+		}
+		decl.KeyType, decl.Type = key, value
+	} else if p.peekToken.Type == LBRACKET {
+		// Optional array suffix: type[size] name or type[] name
+		p.nextToken() // consume type or ')'
+		decl.Array = true
+
+		if p.peekToken.Type != RBRACKET {
+			p.nextToken() // consume '['
+			decl.Size = p.parseExpression(LOWEST)
+		}
+
+		if !p.expectPeek(RBRACKET) {
+			return nil
+		}
+	}
 
-	// Check for optional range: type(min, max) name
+	if !p.expectPeek(IDENT) {
+		return nil
+	}
+
+	decl.Name = &Identifier{Token: p.curToken, Value: p.curToken.Literal}
+	return decl
+}
+
+// parseTypeRefinement parses the optional range and distribution after a type keyword:
+// int(1, 6), bool(0.3), string(3, 8, "abc") with a charset or float(0, 200) ~ normal(100, 15)
+func (p *Parser) parseTypeRefinement(decl *VarDecl) bool {
 	if p.peekToken.Type == LPAREN {
 		p.nextToken() // consume type
 		p.nextToken() // consume '('
 
-		stmt.RangeMin = p.parseExpression(LOWEST)
+		decl.RangeMin = p.parseExpression(LOWEST)
 
-		if !p.expectPeek(COMMA) {
-			return nil
+		if p.peekToken.Type != RPAREN {
+			if !p.expectPeek(COMMA) {
+				return false
+			}
+
+			p.nextToken() // consume comma
+			decl.RangeMax = p.parseExpression(LOWEST)
 		}
 
-		p.nextToken() // consume comma
-		stmt.RangeMax = p.parseExpression(LOWEST)
+		if p.peekToken.Type == COMMA {
+			p.nextToken() // consume comma
+			p.nextToken()
+			decl.Charset = p.parseExpression(LOWEST)
+		}
 
 		if !p.expectPeek(RPAREN) {
-			return nil
+			return false
+		}
+	}
+
+	// Optional distribution: float ~ normal(100, 15) iq
+	if p.peekToken.Type == TILDE {
+		p.nextToken()
+		decl.Distribution = p.parseDistribution(decl.Token)
+		if decl.Distribution == nil {
+			return false
 		}
 	}
+	return true
+}
+
+// parseTypeDeclaration parses a named type: type Dice = int(1, 7);
+func (p *Parser) parseTypeDeclaration() Statement {
+	stmt := &TypeDecl{Token: p.curToken}
 
 	if !p.expectPeek(IDENT) {
 		return nil
 	}
-
 	stmt.Name = &Identifier{Token: p.curToken, Value: p.curToken.Literal}
 
-	// Optional assignment: = value
-	if p.peekToken.Type == ASSIGN {
-		p.nextToken() // consume name
-		p.nextToken() // consume '='
-		stmt.Value = p.parseExpression(LOWEST)
+	declared := p.isDeclaredType(stmt.Name.Value)
+	if declared {
+		p.errors = append(p.errors, NewParserError(p.curToken.Position(),
+			"type %s is already declared", stmt.Name.Value))
+	}
+	p.checkReservedTypeName(stmt.Name)
+	// Like structs and enums, named types belong to the top level, where their bounds are evaluated once
+	if p.blockDepth > 0 {
+		p.errors = append(p.errors, NewParserError(stmt.Token.Position(),
+			"type %s must be declared at the top level", stmt.Name.Value))
+	}
+
+	if !p.expectPeek(ASSIGN) {
+		return nil
+	}
+	p.nextToken()
+
+	if !isKeyTypeToken(p.curToken.Type) {
+		p.errors = append(p.errors, NewParserError(p.curToken.Position(),
+			"expected int, uint, float, unofloat, bool or string as the base of type %s, got %s",
+			stmt.Name.Value, p.curToken.Literal))
+		for p.peekToken.Type != SEMICOLON && p.peekToken.Type != EOF {
+			p.nextToken()
+		}
+		p.nextToken()
+		return nil
+	}
+
+	stmt.Base = &VarDecl{Token: p.curToken, Type: p.curToken.Type}
+	if !p.parseTypeRefinement(stmt.Base) {
+		return nil
 	}
 
+	if p.peekToken.Type == LBRACKET {
+		p.errors = append(p.errors, NewParserError(p.peekToken.Position(),
+			"type %s cannot be an array, declare %s[n] variables instead", stmt.Name.Value, stmt.Name.Value))
+		return nil
+	}
 	if p.peekToken.Type == SEMICOLON {
 		p.nextToken()
 	}
 
+	if !declared {
+		p.typeNames[stmt.Name.Value] = stmt
+	}
 	return stmt
 }
 
+// parseDistribution parses ~ name(args) after the numeric type typeTok
+func (p *Parser) parseDistribution(typeTok Token) *Distribution {
+	dist := &Distribution{Token: p.curToken}
+
+	if !p.expectPeek(IDENT) {
+		return nil
+	}
+	dist.Name = &Identifier{Token: p.curToken, Value: p.curToken.Literal}
+
+	if !p.expectPeek(LPAREN) {
+		return nil
+	}
+
+	dist.Args = p.parseExpressionList(RPAREN)
+	if dist.Args == nil {
+		return nil
+	}
+
+	// The rest of the declaration is still parsed, so these errors do not cascade
+	spec, ok := distributions[dist.Name.Value]
+	switch {
+	case !isNumericTypeToken(typeTok.Type):
+		p.errors = append(p.errors, NewParserError(dist.Token.Position(),
+			"%s declarations cannot have a distribution", typeTok.Literal))
+	case !ok:
+		p.errors = append(p.errors, NewParserError(dist.Name.Token.Position(),
+			"unknown distribution %s", dist.Name.Value))
+	case len(dist.Args) != len(spec.params):
+		p.errors = append(p.errors, NewParserError(dist.Name.Token.Position(),
+			"%s takes %d arguments (%s), got %d",
+			dist.Name.Value, len(spec.params), strings.Join(spec.params, ", "), len(dist.Args)))
+	}
+	return dist
+}
+
+// parseMapTypeSuffix parses the [key]value part of a map type with peekToken on '['
+func (p *Parser) parseMapTypeSuffix() (TokenType, TokenType, bool) {
+	if !p.expectPeek(LBRACKET) {
+		return "", "", false
+	}
+
+	p.nextToken()
+	if !isKeyTypeToken(p.curToken.Type) {
+		p.errors = append(p.errors, NewParserError(
+			p.curToken.Position(),
+			"invalid map key type %s", p.curToken.Type))
+		return "", "", false
+	}
+	key := p.curToken.Type
+
+	if !p.expectPeek(RBRACKET) {
+		return "", "", false
+	}
+
+	p.nextToken()
+	if !isTypeToken(p.curToken.Type) || p.curToken.Type == TYPE_MAP {
+		p.errors = append(p.errors, NewParserError(
+			p.curToken.Position(),
+			"invalid map value type %s", p.curToken.Type))
+		return "", "", false
+	}
+
+	return key, p.curToken.Type, true
+}
+
 func (p *Parser) parseAssignStatement() Statement {
 	stmt := &AssignStmt{Name: &Identifier{Token: p.curToken, Value: p.curToken.Literal}}
 
@@ -214,11 +574,49 @@ func (p *Parser) parseAssignStatement() Statement {
 	return stmt
 }
 
-func (p *Parser) parseExpressionStatement() *ExprStmt {
+func (p *Parser) parseExpressionStatement() Statement {
 	stmt := &ExprStmt{Token: p.curToken}
 
 	stmt.Expression = p.parseExpression(LOWEST)
 
+	// An index expression followed by '=' is an element assignment: xs[i] = value
+	if target, ok := stmt.Expression.(*IndexExpr); ok && p.peekToken.Type == ASSIGN {
+		return p.parseIndexAssignStatement(target)
+	}
+
+	// A field access followed by '=' is a field assignment: u.age = value
+	if target, ok := stmt.Expression.(*MemberExpr); ok && p.peekToken.Type == ASSIGN {
+		return p.parseMemberAssignStatement(target)
+	}
+
+	if p.peekToken.Type == SEMICOLON {
+		p.nextToken()
+	}
+
+	return stmt
+}
+
+func (p *Parser) parseIndexAssignStatement(target *IndexExpr) Statement {
+	p.nextToken() // consume ']'
+	stmt := &IndexAssignStmt{Token: p.curToken, Target: target}
+
+	p.nextToken() // consume '='
+	stmt.Value = p.parseExpression(LOWEST)
+
+	if p.peekToken.Type == SEMICOLON {
+		p.nextToken()
+	}
+
+	return stmt
+}
+
+func (p *Parser) parseMemberAssignStatement(target *MemberExpr) Statement {
+	p.nextToken() // consume field name
+	stmt := &MemberAssignStmt{Token: p.curToken, Target: target}
+
+	p.nextToken() // consume '='
+	stmt.Value = p.parseExpression(LOWEST)
+
 	if p.peekToken.Type == SEMICOLON {
 		p.nextToken()
 	}
@@ -254,7 +652,9 @@ func (p *Parser) parseIdentifier() Expression {
 func (p *Parser) parseIntegerLiteral() Expression {
 	lit := &IntegerLiteral{Token: p.curToken}
 
-	value, err := strconv.ParseInt(p.curToken.Literal, 0, 64)
+	// Only 0x, 0b and 0o select a base, so 017 is seventeen rather than octal
+	digits, base := numberDigits(p.curToken.Literal)
+	value, err := strconv.ParseInt(digits, base, 64)
 	if err != nil {
 		p.errors = append(p.errors, NewIntegerParseError(&p.curToken))
 		return nil
@@ -264,10 +664,25 @@ func (p *Parser) parseIntegerLiteral() Expression {
 	return lit
 }
 
+func (p *Parser) parseUintLiteral() Expression {
+	lit := &UintLiteral{Token: p.curToken}
+
+	digits, base := numberDigits(strings.TrimSuffix(p.curToken.Literal, "u"))
+	value, err := strconv.ParseUint(digits, base, 64)
+	if err != nil {
+		p.errors = append(p.errors, NewUintParseError(&p.curToken))
+		return nil
+	}
+
+	lit.Value = value
+	return lit
+}
+
 func (p *Parser) parseFloatLiteral() Expression {
 	lit := &FloatLiteral{Token: p.curToken}
 
-	value, err := strconv.ParseFloat(p.curToken.Literal, 64)
+	digits, _ := numberDigits(p.curToken.Literal)
+	value, err := strconv.ParseFloat(digits, 64)
 	if err != nil {
 		p.errors = append(p.errors, NewFloatParseError(&p.curToken))
 		return nil
@@ -277,12 +692,125 @@ func (p *Parser) parseFloatLiteral() Expression {
 	return lit
 }
 
+func (p *Parser) parsePercentLiteral() Expression {
+	lit := &PercentLiteral{Token: p.curToken}
+
+	digits, _ := numberDigits(strings.TrimSuffix(p.curToken.Literal, "%"))
+	value, err := strconv.ParseFloat(digits, 64)
+	if err != nil || value < 0 || value > 100 {
+		p.errors = append(p.errors, NewPercentParseError(&p.curToken))
+		return nil
+	}
+
+	lit.Value = value / 100
+	return lit
+}
+
+// numberDigits strips the digit separators from a number literal and returns
+// the base that strconv should parse it with
+func numberDigits(lit string) (string, int) {
+	digits := strings.ReplaceAll(lit, "_", "")
+	unsigned := strings.TrimLeft(digits, "+-")
+	if len(unsigned) > 1 && unsigned[0] == '0' && strings.ContainsRune("xXbBoO", rune(unsigned[1])) {
+		return digits, 0
+	}
+	return digits, 10
+}
+
 func (p *Parser) parseBoolean() Expression {
 	return &BooleanLiteral{Token: p.curToken, Value: p.curToken.Type == TRUE}
 }
 
+// parseIllegal reports the error the lexer attached to an ILLEGAL token
+func (p *Parser) parseIllegal() Expression {
+	p.errors = append(p.errors, NewIllegalTokenError(&p.curToken))
+	return nil
+}
+
 func (p *Parser) parseStringLiteral() Expression {
-	return &StringLiteral{Token: p.curToken, Value: p.curToken.Literal}
+	body, _, raw := stringBody(p.curToken.Literal)
+	if !raw {
+		body = unescape(body)
+	}
+	return &StringLiteral{Token: p.curToken, Value: body}
+}
+
+// parseTemplateString splits a string with ${...} interpolations into its text
+// and the embedded expressions, which are parsed at their position in the file.
+// \$ keeps a literal $, so "\${x}" is not interpolated.
+func (p *Parser) parseTemplateString() Expression {
+	tok := p.curToken
+	template := &TemplateString{Token: tok}
+	body, offset, _ := stringBody(tok.Literal)
+
+	textStart := 0
+	flush := func(textEnd int) {
+		if textEnd > textStart {
+			raw := body[textStart:textEnd]
+			textTok := Token{Type: STRING, Literal: `"` + raw + `"`, File: tok.File, Line: tok.Line, Column: tok.Column}
+			template.Parts = append(template.Parts, &StringLiteral{Token: textTok, Value: unescape(raw)})
+		}
+	}
+
+	for idx := 0; idx < len(body); idx++ {
+		switch {
+		case body[idx] == '\\':
+			// Skip the escaped character, \$ in particular
+			idx++
+		case body[idx] == '$' && idx+1 < len(body) && body[idx+1] == '{':
+			flush(idx)
+			start := idx + 2
+			end := start + interpolationEnd(body[start:])
+			expr := p.parseEmbeddedExpression(body[start:end], offsetPosition(tok, tok.Literal[:offset+start]))
+			if expr == nil {
+				return nil
+			}
+			template.Parts = append(template.Parts, expr)
+			idx = end
+			textStart = end + 1
+		}
+	}
+	flush(len(body))
+
+	return template
+}
+
+// parseEmbeddedExpression parses the source of a ${...} interpolation found at pos
+func (p *Parser) parseEmbeddedExpression(src string, pos *Position) Expression {
+	if strings.TrimSpace(src) == "" {
+		p.errors = append(p.errors, NewParserError(pos, "empty interpolation"))
+		return nil
+	}
+
+	sub := NewParser(newLexerAt(pos.File, src, pos.Line, pos.Column))
+	expr := sub.parseExpression(LOWEST)
+	if len(sub.errors) == 0 && sub.peekToken.Type != EOF {
+		sub.errors = append(sub.errors, NewParserError(sub.peekToken.Position(), "unexpected %s in interpolation", sub.peekToken.Literal))
+	}
+	// Drain the lexer so its goroutine finishes, a closed lexer returns empty tokens
+	for sub.curToken.Type != EOF && sub.peekToken.Type != EOF && sub.peekToken.Type != "" {
+		sub.nextToken()
+	}
+
+	if len(sub.errors) > 0 {
+		p.errors = append(p.errors, sub.errors...)
+		return nil
+	}
+	return expr
+}
+
+// offsetPosition returns the position right after prefix, which starts at tok
+func offsetPosition(tok Token, prefix string) *Position {
+	pos := tok.Position()
+	for _, ch := range prefix {
+		if ch == '\n' {
+			pos.Line++
+			pos.Column = 1
+		} else {
+			pos.Column++
+		}
+	}
+	return pos
 }
 
 func (p *Parser) parseGroupedExpression() Expression {
@@ -320,57 +848,197 @@ func (p *Parser) parseInfixExpression(left Expression) Expression {
 	return expression
 }
 
-func (p *Parser) parseCallExpression(function Expression) Expression {
-	exp := &CallExpr{Token: p.curToken, Function: function}
-	exp.Arguments = p.parseCallArguments()
-	return exp
+func (p *Parser) parseRangeExpression(start Expression) Expression {
+	expression := &RangeExpr{Token: p.curToken, Start: start}
+
+	precedence := p.curPrecedence()
+	p.nextToken()
+	expression.End = p.parseExpression(precedence)
+
+	return expression
 }
 
-func (p *Parser) parseCallArguments() []Expression {
-	args := []Expression{}
+// parseConditionalExpression parses cond ? a : b
+func (p *Parser) parseConditionalExpression(condition Expression) Expression {
+	expression := &ConditionalExpr{Token: p.curToken, Condition: condition}
+	return p.parseConditionalBranches(expression)
+}
 
-	if p.peekToken.Type == RPAREN {
-		p.nextToken()
-		return args
-	}
+// parseIfrandExpression parses the random pick ifrand(p) ? a : b, the probability is optional
+func (p *Parser) parseIfrandExpression() Expression {
+	expression := &ConditionalExpr{Token: p.curToken}
 
-	p.nextToken()
-	args = append(args, p.parseExpression(LOWEST))
+	if p.peekToken.Type == LPAREN {
+		p.nextToken() // consume ifrand
+		p.nextToken() // consume (
+		expression.Condition = p.parseExpression(LOWEST)
 
-	for p.peekToken.Type == COMMA {
-		p.nextToken() // consume comma
-		p.nextToken() // consume expression
-		args = append(args, p.parseExpression(LOWEST))
+		if !p.expectPeek(RPAREN) {
+			return nil
+		}
 	}
 
-	if !p.expectPeek(RPAREN) {
+	if !p.expectPeek(QUESTION) {
 		return nil
 	}
-
-	return args
+	return p.parseConditionalBranches(expression)
 }
 
-func (p *Parser) expectPeek(t TokenType) bool {
-	if p.peekToken.Type == t {
-		p.nextToken()
-		return true
-	}
-	p.peekError(t)
-	return false
-}
+// parseConditionalBranches parses the "a : b" part of a conditional expression,
+// with the current token on the '?'. The alternative is parsed at the lowest
+// precedence so that a ? b : c ? d : e groups as a ? b : (c ? d : e).
+func (p *Parser) parseConditionalBranches(expression *ConditionalExpr) Expression {
+	p.nextToken()
+	expression.Consequence = p.parseExpression(LOWEST)
 
-func (p *Parser) peekPrecedence() int {
-	if p, ok := precedences[p.peekToken.Type]; ok {
-		return p
+	if !p.expectPeek(COLON) {
+		return nil
 	}
-	return LOWEST
-}
 
-func (p *Parser) curPrecedence() int {
-	if p, ok := precedences[p.curToken.Type]; ok {
-		return p
-	}
-	return LOWEST
+	p.nextToken()
+	expression.Alternative = p.parseExpression(LOWEST)
+
+	return expression
+}
+
+// parseTypedRange parses a random range used as an expression, e.g. int(1, 6)
+func (p *Parser) parseTypedRange() Expression {
+	expression := &RangeExpr{Token: p.curToken, Type: p.curToken.Type}
+
+	if !p.expectPeek(LPAREN) {
+		return nil
+	}
+
+	p.nextToken()
+	expression.Start = p.parseExpression(LOWEST)
+
+	if !p.expectPeek(COMMA) {
+		return nil
+	}
+
+	p.nextToken()
+	expression.End = p.parseExpression(LOWEST)
+
+	if !p.expectPeek(RPAREN) {
+		return nil
+	}
+
+	return expression
+}
+
+func (p *Parser) parseCallExpression(function Expression) Expression {
+	exp := &CallExpr{Token: p.curToken, Function: function}
+	exp.Arguments = p.parseExpressionList(RPAREN)
+	return exp
+}
+
+func (p *Parser) parseArrayLiteral() Expression {
+	array := &ArrayLiteral{Token: p.curToken}
+	array.Elements = p.parseExpressionList(RBRACKET)
+	if array.Elements == nil {
+		return nil
+	}
+	return array
+}
+
+func (p *Parser) parseIndexExpression(left Expression) Expression {
+	exp := &IndexExpr{Token: p.curToken, Left: left}
+
+	p.nextToken() // consume '['
+	exp.Index = p.parseExpression(LOWEST)
+
+	if !p.expectPeek(RBRACKET) {
+		return nil
+	}
+
+	return exp
+}
+
+func (p *Parser) parseMapLiteral() Expression {
+	literal := &MapLiteral{Token: p.curToken}
+
+	for p.peekToken.Type != RBRACE {
+		p.nextToken()
+		key := p.parseExpression(LOWEST)
+
+		if !p.expectPeek(COLON) {
+			return nil
+		}
+
+		p.nextToken() // consume ':'
+		literal.Keys = append(literal.Keys, key)
+		literal.Values = append(literal.Values, p.parseExpression(LOWEST))
+
+		if p.peekToken.Type != RBRACE && !p.expectPeek(COMMA) {
+			return nil
+		}
+	}
+
+	if !p.expectPeek(RBRACE) {
+		return nil
+	}
+
+	return literal
+}
+
+func (p *Parser) parseMemberExpression(object Expression) Expression {
+	exp := &MemberExpr{Token: p.curToken, Object: object}
+
+	if !p.expectPeek(IDENT) {
+		return nil
+	}
+	exp.Member = &Identifier{Token: p.curToken, Value: p.curToken.Literal}
+
+	return exp
+}
+
+// parseExpressionList parses comma separated expressions up to the end token,
+// as used by call arguments and array literals
+func (p *Parser) parseExpressionList(end TokenType) []Expression {
+	args := []Expression{}
+
+	if p.peekToken.Type == end {
+		p.nextToken()
+		return args
+	}
+
+	p.nextToken()
+	args = append(args, p.parseExpression(LOWEST))
+
+	for p.peekToken.Type == COMMA {
+		p.nextToken() // consume comma
+		p.nextToken() // consume expression
+		args = append(args, p.parseExpression(LOWEST))
+	}
+
+	if !p.expectPeek(end) {
+		return nil
+	}
+
+	return args
+}
+
+func (p *Parser) expectPeek(t TokenType) bool {
+	if p.peekToken.Type == t {
+		p.nextToken()
+		return true
+	}
+	p.peekError(t)
+	return false
+}
+
+func (p *Parser) peekPrecedence() int {
+	if p, ok := precedences[p.peekToken.Type]; ok {
+		return p
+	}
+	return LOWEST
+}
+
+func (p *Parser) curPrecedence() int {
+	if p, ok := precedences[p.curToken.Type]; ok {
+		return p
+	}
+	return LOWEST
 }
 
 func (p *Parser) noPrefixParseFnError(t TokenType) {
@@ -432,19 +1100,815 @@ func (p *Parser) parseIfStatement() *IfStmt {
 	return stmt
 }
 
-func (p *Parser) parseBlockStatement() *BlockStmt {
-	block := &BlockStmt{Token: p.curToken}
-	block.Statements = []Statement{}
+func (p *Parser) parseWhileStatement() *WhileStmt {
+	stmt := &WhileStmt{Token: p.curToken}
+
+	// whilerand may omit its probability, while requires a condition
+	if p.curToken.Type == WHILE || p.peekToken.Type == LPAREN {
+		if !p.expectPeek(LPAREN) {
+			return nil
+		}
+
+		p.nextToken()
+		stmt.Condition = p.parseExpression(LOWEST)
+
+		if !p.expectPeek(RPAREN) {
+			return nil
+		}
+	}
+
+	if !p.expectPeek(LBRACE) {
+		return nil
+	}
+
+	stmt.Body = p.parseBlockStatement()
+
+	return stmt
+}
+
+func (p *Parser) parseRepeatStatement() *RepeatStmt {
+	stmt := &RepeatStmt{Token: p.curToken}
+
+	if p.curToken.Type == REPEATRAND {
+		// repeatrand(min, max) { ... }
+		if !p.expectPeek(LPAREN) {
+			return nil
+		}
+
+		p.nextToken()
+		stmt.Min = p.parseExpression(LOWEST)
+
+		if !p.expectPeek(COMMA) {
+			return nil
+		}
+
+		p.nextToken()
+		stmt.Max = p.parseExpression(LOWEST)
+
+		if !p.expectPeek(RPAREN) {
+			return nil
+		}
+	} else {
+		// repeat count { ... }
+		p.nextToken()
+		stmt.Count = p.parseExpression(LOWEST)
+	}
+
+	if !p.expectPeek(LBRACE) {
+		return nil
+	}
+
+	stmt.Body = p.parseBlockStatement()
+
+	return stmt
+}
+
+func (p *Parser) parseForStatement() Statement {
+	if p.peekToken.Type == LPAREN {
+		return p.parseCStyleForStatement()
+	}
+
+	stmt := &ForInStmt{Token: p.curToken}
+
+	if !p.expectPeek(IDENT) {
+		return nil
+	}
+	stmt.Variable = &Identifier{Token: p.curToken, Value: p.curToken.Literal}
+
+	if !p.expectPeek(IN) {
+		return nil
+	}
 
 	p.nextToken()
+	stmt.Iterable = p.parseExpression(LOWEST)
 
-	for p.curToken.Type != RBRACE && p.curToken.Type != EOF {
-		stmt := p.parseStatement()
-		if stmt != nil {
-			block.Statements = append(block.Statements, stmt)
+	if !p.expectPeek(LBRACE) {
+		return nil
+	}
+
+	stmt.Body = p.parseBlockStatement()
+
+	return stmt
+}
+
+func (p *Parser) parseCStyleForStatement() Statement {
+	stmt := &ForStmt{Token: p.curToken}
+
+	p.nextToken() // consume for
+	p.nextToken() // consume (
+
+	// Init clause: for (int i = 0; ...) or for (i = 0; ...)
+	if p.curToken.Type != SEMICOLON {
+		switch {
+		case p.isTypeName(p.curToken):
+			stmt.Init = p.parseVarStatement()
+		case p.curToken.Type == IDENT && p.peekToken.Type == ASSIGN:
+			stmt.Init = p.parseAssignStatement()
+		default:
+			p.errors = append(p.errors, NewParserError(
+				p.curToken.Position(),
+				"for loop init must be a declaration or an assignment, got %s", p.curToken.Type))
+			return nil
+		}
+
+		if p.curToken.Type != SEMICOLON {
+			p.peekError(SEMICOLON)
+			return nil
 		}
+	}
+
+	// Condition clause, an empty condition loops until break
+	if p.peekToken.Type != SEMICOLON {
+		p.nextToken()
+		stmt.Condition = p.parseExpression(LOWEST)
+	}
+
+	if !p.expectPeek(SEMICOLON) {
+		return nil
+	}
+
+	// Post clause
+	if p.peekToken.Type != RPAREN {
 		p.nextToken()
+		stmt.Post = p.parseStatement()
 	}
 
-	return block
+	if !p.expectPeek(RPAREN) {
+		return nil
+	}
+
+	if !p.expectPeek(LBRACE) {
+		return nil
+	}
+
+	stmt.Body = p.parseBlockStatement()
+
+	return stmt
+}
+
+func (p *Parser) parseFuncDeclaration() Statement {
+	// A function-typed variable (func f = ...;) is parsed like any other declaration
+	if p.peekToken.Type == IDENT {
+		funcTok := p.curToken
+		p.nextToken()
+		if p.peekToken.Type != LPAREN {
+			stmt := &VarDecl{
+				Token: funcTok,
+				Type:  FUNC,
+				Name:  &Identifier{Token: p.curToken, Value: p.curToken.Literal},
+			}
+			if p.peekToken.Type == ASSIGN {
+				p.nextToken() // consume name
+				p.nextToken() // consume '='
+				stmt.Value = p.parseExpression(LOWEST)
+			}
+			if p.peekToken.Type == SEMICOLON {
+				p.nextToken()
+			}
+			return stmt
+		}
+		return p.parseNamedFunction(funcTok)
+	}
+
+	p.peekError(IDENT)
+	return nil
+}
+
+// parseNamedFunction parses the rest of func name(params) type { ... } with curToken on the name
+func (p *Parser) parseNamedFunction(funcTok Token) Statement {
+	stmt := &FuncDecl{Token: funcTok}
+	stmt.Name = &Identifier{Token: p.curToken, Value: p.curToken.Literal}
+
+	stmt.Function = p.parseFunctionSignatureAndBody(stmt.Token)
+	if stmt.Function == nil {
+		return nil
+	}
+
+	return stmt
+}
+
+func (p *Parser) parseFunctionLiteral() Expression {
+	fn := p.parseFunctionSignatureAndBody(p.curToken)
+	if fn == nil {
+		return nil
+	}
+	return fn
+}
+
+// parseFunctionSignatureAndBody parses (params) type { body } with curToken just before '('
+func (p *Parser) parseFunctionSignatureAndBody(tok Token) *FunctionLiteral {
+	fn := &FunctionLiteral{Token: tok}
+
+	if !p.expectPeek(LPAREN) {
+		return nil
+	}
+
+	fn.Parameters = p.parseFunctionParameters()
+	if fn.Parameters == nil {
+		return nil
+	}
+
+	// Optional return type, possibly an array, a map or a struct: int, int[], map[string]int or User
+	if p.isTypeName(p.peekToken) {
+		p.nextToken()
+		fn.ReturnType = p.curToken.Type
+		if named, ok := p.typeNames[p.curToken.Literal]; ok && fn.ReturnType == IDENT {
+			// Only the base type of a named type is checked for return values
+			fn.ReturnType = named.Base.Type
+		} else if fn.ReturnType == IDENT {
+			fn.ReturnName = p.curToken.Literal
+			if p.enumNames[fn.ReturnName] {
+				fn.ReturnType = ENUM
+			}
+		}
+
+		if fn.ReturnType == TYPE_MAP {
+			key, value, ok := p.parseMapTypeSuffix()
+			if !ok {
+				return nil
+			}
+			fn.ReturnKey, fn.ReturnType = key, value
+		} else if p.peekToken.Type == LBRACKET {
+			p.nextToken()
+			if !p.expectPeek(RBRACKET) {
+				return nil
+			}
+			fn.ReturnsArr = true
+		}
+	}
+
+	if !p.expectPeek(LBRACE) {
+		return nil
+	}
+
+	fn.Body = p.parseBlockStatement()
+
+	return fn
+}
+
+func (p *Parser) parseFunctionParameters() []*VarDecl {
+	params := []*VarDecl{}
+
+	if p.peekToken.Type == RPAREN {
+		p.nextToken()
+		return params
+	}
+
+	for {
+		p.nextToken()
+		if !p.isTypeName(p.curToken) {
+			p.errors = append(p.errors, NewParserError(
+				p.curToken.Position(),
+				"expected parameter type, got %s", p.curToken.Type))
+			return nil
+		}
+
+		param := p.parseTypedName()
+		if param == nil {
+			return nil
+		}
+		for _, prev := range params {
+			if prev.Name.Value == param.Name.Value {
+				p.errors = append(p.errors, NewParserError(param.Name.Token.Position(),
+					"duplicate parameter %s", param.Name.Value))
+			}
+		}
+		params = append(params, param)
+
+		if p.peekToken.Type != COMMA {
+			break
+		}
+		p.nextToken() // consume comma
+	}
+
+	if !p.expectPeek(RPAREN) {
+		return nil
+	}
+
+	return params
+}
+
+func (p *Parser) parseReturnStatement() *ReturnStmt {
+	stmt := &ReturnStmt{Token: p.curToken}
+
+	if p.peekToken.Type != SEMICOLON && p.peekToken.Type != RBRACE {
+		p.nextToken()
+		stmt.Value = p.parseExpression(LOWEST)
+	}
+
+	if p.peekToken.Type == SEMICOLON {
+		p.nextToken()
+	}
+
+	return stmt
+}
+
+func (p *Parser) parseBreakStatement() *BreakStmt {
+	stmt := &BreakStmt{Token: p.curToken}
+
+	if p.peekToken.Type == SEMICOLON {
+		p.nextToken()
+	}
+
+	return stmt
+}
+
+func (p *Parser) parseContinueStatement() *ContinueStmt {
+	stmt := &ContinueStmt{Token: p.curToken}
+
+	if p.peekToken.Type == SEMICOLON {
+		p.nextToken()
+	}
+
+	return stmt
+}
+
+func (p *Parser) parseBlockStatement() *BlockStmt {
+	block := &BlockStmt{Token: p.curToken}
+	block.Statements = []Statement{}
+
+	p.blockDepth++
+	defer func() { p.blockDepth-- }()
+
+	p.nextToken()
+
+	for p.curToken.Type != RBRACE && p.curToken.Type != EOF {
+		stmt := p.parseStatement()
+		if stmt != nil {
+			block.Statements = append(block.Statements, stmt)
+		}
+		p.nextToken()
+	}
+
+	return block
+}
+
+// checkReservedTypeName reports a type declaration named Error, the type of caught errors
+func (p *Parser) checkReservedTypeName(name *Identifier) {
+	if name.Value == ErrorTypeName {
+		p.errors = append(p.errors, NewParserError(name.Token.Position(),
+			"type name %s is reserved for caught errors", name.Value))
+	}
+}
+
+// isTypeName reports whether tok names a type, either a type keyword, a declared struct, a named type or an enum
+func (p *Parser) isTypeName(tok Token) bool {
+	if tok.Type != IDENT {
+		return isTypeToken(tok.Type)
+	}
+	return p.isDeclaredType(tok.Literal)
+}
+
+// isDeclaredType reports whether name is a struct, named type or enum declared so far
+func (p *Parser) isDeclaredType(name string) bool {
+	_, named := p.typeNames[name]
+	return named || p.structNames[name] || p.enumNames[name]
+}
+
+func (p *Parser) parseStructDeclaration() Statement {
+	stmt := &StructDecl{Token: p.curToken}
+
+	if !p.expectPeek(IDENT) {
+		return nil
+	}
+	stmt.Name = &Identifier{Token: p.curToken, Value: p.curToken.Literal}
+
+	if _, ok := p.typeNames[stmt.Name.Value]; ok || p.enumNames[stmt.Name.Value] {
+		p.errors = append(p.errors, NewParserError(p.curToken.Position(),
+			"type %s is already declared", stmt.Name.Value))
+		return nil
+	}
+	p.checkReservedTypeName(stmt.Name)
+	// Struct types are global to the program, so a block that runs twice would declare one twice
+	if p.blockDepth > 0 {
+		p.errors = append(p.errors, NewParserError(stmt.Token.Position(),
+			"struct %s must be declared at the top level", stmt.Name.Value))
+	}
+
+	// Registered before the fields are parsed, so a struct can refer to its own name
+	p.structNames[stmt.Name.Value] = true
+
+	if !p.expectPeek(LBRACE) {
+		return nil
+	}
+
+	fields := make(map[string]bool)
+	for p.peekToken.Type != RBRACE && p.peekToken.Type != EOF {
+		p.nextToken()
+
+		var field Statement
+		switch {
+		case p.curToken.Type == CONST:
+			field = p.parseConstStatement()
+		case p.isTypeName(p.curToken):
+			field = p.parseVarStatement()
+		case p.curToken.Type == IDENT && (p.peekToken.Type == IDENT || p.peekToken.Type == LBRACKET):
+			// A struct declared further down, checked once the whole file is parsed
+			p.fieldTypes = append(p.fieldTypes, p.curToken)
+			field = p.parseVarStatement()
+		default:
+			p.errors = append(p.errors, NewParserError(
+				p.curToken.Position(),
+				"expected field type, got %s", p.curToken.Type))
+			return nil
+		}
+
+		decl, ok := field.(*VarDecl)
+		if !ok || decl == nil {
+			return nil
+		}
+		if fields[decl.Name.Value] {
+			p.errors = append(p.errors, NewParserError(decl.Name.Token.Position(),
+				"duplicate field %s in struct %s", decl.Name.Value, stmt.Name.Value))
+		}
+		fields[decl.Name.Value] = true
+		stmt.Fields = append(stmt.Fields, decl)
+	}
+
+	if !p.expectPeek(RBRACE) {
+		return nil
+	}
+
+	return stmt
+}
+
+// checkFieldTypes reports the field types that did not turn out to be structs. Enums and
+// named types decide how a field is parsed, so they must be declared before their first use.
+func (p *Parser) checkFieldTypes() {
+	for _, tok := range p.fieldTypes {
+		switch {
+		case p.structNames[tok.Literal]:
+		case p.isDeclaredType(tok.Literal):
+			p.errors = append(p.errors, NewParserError(tok.Position(),
+				"type %s is used in a field before its declaration", tok.Literal))
+		default:
+			p.errors = append(p.errors, NewParserError(tok.Position(),
+				"unknown field type %s", tok.Literal))
+		}
+	}
+}
+
+// parseEnumDeclaration parses enum Color { Red, Green, Blue } or, with a weight
+// for every member, enum Status { Ok: 90, Error: 10 }
+func (p *Parser) parseEnumDeclaration() Statement {
+	stmt := &EnumDecl{Token: p.curToken}
+
+	if !p.expectPeek(IDENT) {
+		return nil
+	}
+	stmt.Name = &Identifier{Token: p.curToken, Value: p.curToken.Literal}
+
+	declared := p.isDeclaredType(stmt.Name.Value)
+	if declared {
+		p.errors = append(p.errors, NewParserError(p.curToken.Position(),
+			"type %s is already declared", stmt.Name.Value))
+	}
+	p.checkReservedTypeName(stmt.Name)
+	// Like structs, enums are global to the program
+	if p.blockDepth > 0 {
+		p.errors = append(p.errors, NewParserError(stmt.Token.Position(),
+			"enum %s must be declared at the top level", stmt.Name.Value))
+	}
+
+	if !p.expectPeek(LBRACE) {
+		return nil
+	}
+
+	seen := make(map[string]bool)
+	for p.peekToken.Type != RBRACE && p.peekToken.Type != EOF {
+		if !p.expectPeek(IDENT) {
+			return nil
+		}
+		member := &EnumMember{Name: &Identifier{Token: p.curToken, Value: p.curToken.Literal}}
+		if seen[member.Name.Value] {
+			p.errors = append(p.errors, NewParserError(p.curToken.Position(),
+				"enum %s already has a member %s", stmt.Name.Value, member.Name.Value))
+		}
+		seen[member.Name.Value] = true
+
+		if p.peekToken.Type == COLON {
+			p.nextToken() // consume name
+			p.nextToken() // consume ':'
+			member.Weight = p.parseExpression(LOWEST)
+		}
+		stmt.Members = append(stmt.Members, member)
+
+		// Members are separated by commas, a trailing comma is allowed
+		if p.peekToken.Type == COMMA {
+			p.nextToken()
+		} else if p.peekToken.Type != RBRACE {
+			p.peekError(RBRACE)
+			return nil
+		}
+	}
+
+	if !p.expectPeek(RBRACE) {
+		return nil
+	}
+
+	if len(stmt.Members) == 0 {
+		p.errors = append(p.errors, NewParserError(stmt.Token.Position(),
+			"enum %s needs at least one member", stmt.Name.Value))
+		return nil
+	}
+	for _, m := range stmt.Members {
+		if (m.Weight == nil) != (stmt.Members[0].Weight == nil) {
+			p.errors = append(p.errors, NewParserError(m.Name.Token.Position(),
+				"enum %s must give a weight to every member or to none", stmt.Name.Value))
+			return nil
+		}
+	}
+
+	if !declared {
+		p.enumNames[stmt.Name.Value] = true
+	}
+	return stmt
+}
+
+// parseImportStatement parses import "dice.wtf"; or import "dice.wtf" as dice;
+func (p *Parser) parseImportStatement() Statement {
+	stmt := &ImportStmt{Token: p.curToken}
+
+	if !p.expectPeek(STRING) {
+		return nil
+	}
+	stmt.Path = p.parseStringLiteral().(*StringLiteral)
+
+	if p.peekToken.Type == AS {
+		p.nextToken() // consume path
+		if !p.expectPeek(IDENT) {
+			return nil
+		}
+		stmt.Alias = &Identifier{Token: p.curToken, Value: p.curToken.Literal}
+	}
+
+	if p.peekToken.Type == SEMICOLON {
+		p.nextToken()
+	}
+
+	return stmt
+}
+
+// parseMatchStatement parses match subject { pattern => { ... }, ..., _ => { ... } }
+func (p *Parser) parseMatchStatement() Statement {
+	stmt := &MatchStmt{Token: p.curToken}
+
+	p.nextToken() // consume match
+	stmt.Subject = p.parseExpression(LOWEST)
+
+	if !p.expectPeek(LBRACE) {
+		return nil
+	}
+
+	hasDefault := false
+	for p.peekToken.Type != RBRACE && p.peekToken.Type != EOF {
+		p.nextToken()
+		arm := &MatchArm{Token: p.curToken}
+
+		if hasDefault {
+			p.errors = append(p.errors, NewParserError(p.curToken.Position(),
+				"unreachable match arm after the default arm _"))
+			p.skipMatchArms()
+			return nil
+		}
+
+		if p.curToken.Type == IDENT && p.curToken.Literal == MatchWildcard {
+			hasDefault = true
+		} else {
+			arm.Pattern = p.parseExpression(LOWEST)
+			if !isMatchPattern(arm.Pattern) && !p.isEnumMember(arm.Pattern) {
+				p.errors = append(p.errors, NewParserError(arm.Token.Position(),
+					"invalid match pattern, expected a literal, an enum member, a range a..b or _"))
+				p.skipMatchArms()
+				return nil
+			}
+		}
+
+		if !p.expectPeek(ARROW) || !p.expectPeek(LBRACE) {
+			p.skipMatchArms()
+			return nil
+		}
+		arm.Body = p.parseBlockStatement()
+		stmt.Arms = append(stmt.Arms, arm)
+
+		// Arms are separated by commas, a trailing comma is allowed
+		switch p.peekToken.Type {
+		case COMMA:
+			p.nextToken()
+		case RBRACE:
+		default:
+			p.errors = append(p.errors, NewParserError(p.peekToken.Position(),
+				"expected , or } after a match arm, got %s", p.peekToken.Type))
+			p.skipMatchArms()
+			return nil
+		}
+	}
+
+	if !p.expectPeek(RBRACE) {
+		return nil
+	}
+
+	if !hasDefault {
+		p.warnings = append(p.warnings, NewParserWarning(stmt.Token.Position(),
+			"match has no default arm _, values that match no arm are ignored"))
+	}
+
+	return stmt
+}
+
+// skipMatchArms skips the rest of a match body after an invalid arm, up to and including
+// its closing brace, so that the arm is reported once instead of causing follow-on errors
+func (p *Parser) skipMatchArms() {
+	depth := 1
+	for p.peekToken.Type != EOF {
+		p.nextToken()
+		switch p.curToken.Type {
+		case LBRACE:
+			depth++
+		case RBRACE:
+			depth--
+			if depth == 0 {
+				return
+			}
+		}
+	}
+}
+
+// isMatchPattern reports whether exp is a literal, a possibly negated number or a range of those
+func isMatchPattern(exp Expression) bool {
+	switch e := exp.(type) {
+	case *IntegerLiteral, *UintLiteral, *FloatLiteral, *PercentLiteral, *StringLiteral, *BooleanLiteral:
+		return true
+	case *UnaryExpr:
+		switch e.Right.(type) {
+		case *IntegerLiteral, *FloatLiteral:
+			return e.Operator == "-"
+		}
+	case *RangeExpr:
+		return e.Type == "" && isMatchPattern(e.Start) && isMatchPattern(e.End) &&
+			!isRangeExpr(e.Start) && !isRangeExpr(e.End)
+	}
+	return false
+}
+
+// isEnumMember reports whether exp names a member of a declared enum, such as Color.Red
+func (p *Parser) isEnumMember(exp Expression) bool {
+	member, ok := exp.(*MemberExpr)
+	if !ok {
+		return false
+	}
+	enum, ok := member.Object.(*Identifier)
+	return ok && p.enumNames[enum.Value]
+}
+
+func isRangeExpr(exp Expression) bool {
+	_, ok := exp.(*RangeExpr)
+	return ok
+}
+
+// parseChooseStatement parses choose { weight => { ... }, ... }
+func (p *Parser) parseChooseStatement() Statement {
+	stmt := &ChooseStmt{Token: p.curToken}
+
+	if !p.expectPeek(LBRACE) {
+		return nil
+	}
+
+	for p.peekToken.Type != RBRACE && p.peekToken.Type != EOF {
+		p.nextToken()
+		arm := &ChooseArm{Token: p.curToken}
+		arm.Weight = p.parseExpression(LOWEST)
+
+		if !p.expectPeek(ARROW) {
+			return nil
+		}
+		if !p.expectPeek(LBRACE) {
+			return nil
+		}
+		arm.Body = p.parseBlockStatement()
+		stmt.Arms = append(stmt.Arms, arm)
+
+		// Arms are separated by commas, a trailing comma is allowed
+		if p.peekToken.Type == COMMA {
+			p.nextToken()
+		}
+	}
+
+	if !p.expectPeek(RBRACE) {
+		return nil
+	}
+
+	if len(stmt.Arms) == 0 {
+		p.errors = append(p.errors, NewParserError(stmt.Token.Position(), "choose needs at least one arm"))
+		return nil
+	}
+
+	return stmt
+}
+
+// parseTryStatement parses try { ... } catch (err) { ... }, the error binding is optional
+func (p *Parser) parseTryStatement() Statement {
+	stmt := &TryStmt{Token: p.curToken}
+
+	if !p.expectPeek(LBRACE) {
+		return nil
+	}
+	stmt.Body = p.parseBlockStatement()
+
+	if !p.expectPeek(CATCH) {
+		return nil
+	}
+
+	if p.peekToken.Type == LPAREN {
+		p.nextToken()
+		if !p.expectPeek(IDENT) {
+			return nil
+		}
+		stmt.Param = &Identifier{Token: p.curToken, Value: p.curToken.Literal}
+		if !p.expectPeek(RPAREN) {
+			return nil
+		}
+	}
+
+	if !p.expectPeek(LBRACE) {
+		return nil
+	}
+	stmt.Handler = p.parseBlockStatement()
+
+	return stmt
+}
+
+// parseThrowStatement parses throw "message";
+func (p *Parser) parseThrowStatement() Statement {
+	stmt := &ThrowStmt{Token: p.curToken}
+
+	p.nextToken()
+	stmt.Value = p.parseExpression(LOWEST)
+
+	if p.peekToken.Type == SEMICOLON {
+		p.nextToken()
+	}
+
+	return stmt
+}
+
+// parseAssertStatement parses assert(condition); and assert(condition, "message");
+func (p *Parser) parseAssertStatement() Statement {
+	stmt := &AssertStmt{Token: p.curToken}
+
+	if !p.expectPeek(LPAREN) {
+		return nil
+	}
+
+	p.nextToken()
+	stmt.Condition = p.parseExpression(LOWEST)
+
+	if p.peekToken.Type == COMMA {
+		p.nextToken()
+		p.nextToken()
+		stmt.Message = p.parseExpression(LOWEST)
+	}
+
+	if !p.expectPeek(RPAREN) {
+		return nil
+	}
+
+	if p.peekToken.Type == SEMICOLON {
+		p.nextToken()
+	}
+
+	return stmt
+}
+
+// parseRerollStatement parses reroll d;, reroll a, b; or reroll all;
+func (p *Parser) parseRerollStatement() Statement {
+	stmt := &RerollStmt{Token: p.curToken}
+
+	if !p.expectPeek(IDENT) {
+		return nil
+	}
+
+	if p.curToken.Literal == RerollAll && p.peekToken.Type != COMMA {
+		if p.peekToken.Type == SEMICOLON {
+			p.nextToken()
+		}
+		return stmt
+	}
+
+	for {
+		stmt.Names = append(stmt.Names, &Identifier{Token: p.curToken, Value: p.curToken.Literal})
+		if p.peekToken.Type != COMMA {
+			break
+		}
+		p.nextToken() // consume name
+		if !p.expectPeek(IDENT) {
+			return nil
+		}
+	}
+
+	if p.peekToken.Type == SEMICOLON {
+		p.nextToken()
+	}
+
+	return stmt
 }
@@ -1,6 +1,7 @@
 package interpreter
 
 import (
+	"strings"
 	"testing"
 )
 
@@ -145,6 +146,22 @@ func TestOperatorPrecedenceParsing(t *testing.T) {
 			"add(a + b + c * d / f + g)",
 			"add((((a + b) + ((c * d) / f)) + g))",
 		},
+		{
+			"a || b ? c : d",
+			"((a || b) ? c : d)",
+		},
+		{
+			"a > b ? a + 1 : b * 2",
+			"((a > b) ? (a + 1) : (b * 2))",
+		},
+		{
+			"a ? b : c ? d : e",
+			"(a ? b : (c ? d : e))",
+		},
+		{
+			"a ? b ? c : d : e",
+			"(a ? (b ? c : d) : e)",
+		},
 	}
 
 	for _, tt := range tests {
@@ -370,6 +387,162 @@ func TestParser_IfrandWithProbability(t *testing.T) {
 	}
 }
 
+func TestParser_WhileStatement(t *testing.T) {
+	input := `
+	while (x < 10) {
+		x = x + 1;
+		if (x == 5) {
+			break;
+		}
+		continue;
+	}
+	`
+	l := NewLexer("test", input)
+	p := NewParser(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	if len(program.Statements) != 1 {
+		t.Fatalf("expected 1 statement, got %d", len(program.Statements))
+	}
+
+	stmt, ok := program.Statements[0].(*WhileStmt)
+	if !ok {
+		t.Fatalf("statement is not WhileStmt, got %T", program.Statements[0])
+	}
+
+	if stmt.Condition == nil {
+		t.Fatal("condition is nil")
+	}
+
+	if len(stmt.Body.Statements) != 3 {
+		t.Fatalf("expected 3 body statements, got %d", len(stmt.Body.Statements))
+	}
+
+	if _, ok := stmt.Body.Statements[2].(*ContinueStmt); !ok {
+		t.Errorf("last body statement is not ContinueStmt, got %T", stmt.Body.Statements[2])
+	}
+}
+
+func TestParser_ForStatement(t *testing.T) {
+	input := `
+	for (int i = 0; i < 10; i = i + 1) {
+		print(i);
+	}
+	`
+	l := NewLexer("test", input)
+	p := NewParser(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	if len(program.Statements) != 1 {
+		t.Fatalf("expected 1 statement, got %d", len(program.Statements))
+	}
+
+	stmt, ok := program.Statements[0].(*ForStmt)
+	if !ok {
+		t.Fatalf("statement is not ForStmt, got %T", program.Statements[0])
+	}
+
+	if _, ok := stmt.Init.(*VarDecl); !ok {
+		t.Errorf("init is not VarDecl, got %T", stmt.Init)
+	}
+	if stmt.Condition == nil {
+		t.Error("condition is nil")
+	}
+	if _, ok := stmt.Post.(*AssignStmt); !ok {
+		t.Errorf("post is not AssignStmt, got %T", stmt.Post)
+	}
+	if len(stmt.Body.Statements) != 1 {
+		t.Errorf("expected 1 body statement, got %d", len(stmt.Body.Statements))
+	}
+}
+
+func TestParser_ForStatementEmptyClauses(t *testing.T) {
+	l := NewLexer("test", "for (;;) { break; }")
+	p := NewParser(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	stmt, ok := program.Statements[0].(*ForStmt)
+	if !ok {
+		t.Fatalf("statement is not ForStmt, got %T", program.Statements[0])
+	}
+
+	if stmt.Init != nil || stmt.Condition != nil || stmt.Post != nil {
+		t.Errorf("expected empty clauses, got %s", stmt.String())
+	}
+}
+
+func TestParser_ForInStatement(t *testing.T) {
+	tests := []struct {
+		input        string
+		expectedType TokenType
+		expectedStr  string
+	}{
+		{"for i in 0..n + 1 { }", "", "(0..(n + 1))"},
+		{"for i in int(1, 6) { }", TYPE_INT, "int(1, 6)"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			l := NewLexer("test", tt.input)
+			p := NewParser(l)
+			program := p.ParseProgram()
+			checkParserErrors(t, p)
+
+			stmt, ok := program.Statements[0].(*ForInStmt)
+			if !ok {
+				t.Fatalf("statement is not ForInStmt, got %T", program.Statements[0])
+			}
+
+			if stmt.Variable.Value != "i" {
+				t.Errorf("expected loop variable 'i', got %s", stmt.Variable.Value)
+			}
+
+			rangeExpr, ok := stmt.Iterable.(*RangeExpr)
+			if !ok {
+				t.Fatalf("iterable is not RangeExpr, got %T", stmt.Iterable)
+			}
+			if rangeExpr.Type != tt.expectedType {
+				t.Errorf("expected range type %q, got %q", tt.expectedType, rangeExpr.Type)
+			}
+			if rangeExpr.String() != tt.expectedStr {
+				t.Errorf("expected %s, got %s", tt.expectedStr, rangeExpr.String())
+			}
+		})
+	}
+}
+
+func TestParser_RandomLoops(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"repeat 3 { x = x + 1; }", "repeat 3 x = (x + 1);"},
+		{"repeatrand(1, 6) { x = x + 1; }", "repeatrand(1, 6) x = (x + 1);"},
+		{"whilerand(0.9) { x = x + 1; }", "whilerand 0.9 x = (x + 1);"},
+		{"whilerand { x = x + 1; }", "whilerand x = (x + 1);"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			l := NewLexer("test", tt.input)
+			p := NewParser(l)
+			program := p.ParseProgram()
+			checkParserErrors(t, p)
+
+			if len(program.Statements) != 1 {
+				t.Fatalf("expected 1 statement, got %d", len(program.Statements))
+			}
+
+			if str := program.Statements[0].String(); str != tt.expected {
+				t.Errorf("expected %q, got %q", tt.expected, str)
+			}
+		})
+	}
+}
+
 // ============================================================================
 // Parser Tests for Operators
 // ============================================================================
@@ -526,7 +699,9 @@ func TestParser_RangeDeclarations(t *testing.T) {
 	}{
 		{"int_range", "int(0, 100) x;"},
 		{"float_range", "float(0.0, 10.0) x;"},
-		// Note: uint and unofloat may not support range syntax in the implementation
+		{"uint_range", "uint(10, 500) x;"},
+		{"unofloat_range", "unofloat(0.2, 0.8) x;"},
+		{"string_range", "string(3, 8) x;"},
 	}
 
 	for _, tt := range tests {
@@ -552,6 +727,83 @@ func TestParser_RangeDeclarations(t *testing.T) {
 	}
 }
 
+func TestParser_BoolProbabilityDeclaration(t *testing.T) {
+	l := NewLexer("test", "bool(0.3) flag;")
+	p := NewParser(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	stmt, ok := program.Statements[0].(*VarDecl)
+	if !ok {
+		t.Fatalf("statement is not VarDecl, got %T", program.Statements[0])
+	}
+	if stmt.RangeMin == nil || stmt.RangeMax != nil {
+		t.Fatalf("expected only a probability, got %v and %v", stmt.RangeMin, stmt.RangeMax)
+	}
+	if stmt.String() != "bool(0.3) flag;" {
+		t.Errorf("unexpected String() %q", stmt.String())
+	}
+}
+
+func TestParser_DistributionDeclarations(t *testing.T) {
+	tests := []string{
+		"float ~ normal(100, 15) iq;",
+		"int ~ poisson(3) arrivals;",
+		"float ~ exponential(0.5) wait;",
+		"int ~ binomial(10, 0.3) hits;",
+		"float ~ triangular(1, 2, 5) x;",
+		"int(0, 10) ~ normal(5, 2) clipped;",
+		"float ~ normal(0, 1)[10] xs;",
+	}
+
+	for _, input := range tests {
+		t.Run(input, func(t *testing.T) {
+			l := NewLexer("test", input)
+			p := NewParser(l)
+			program := p.ParseProgram()
+			checkParserErrors(t, p)
+
+			stmt, ok := program.Statements[0].(*VarDecl)
+			if !ok {
+				t.Fatalf("statement is not VarDecl, got %T", program.Statements[0])
+			}
+			if stmt.Distribution == nil {
+				t.Fatal("expected a distribution")
+			}
+			if stmt.String() != input {
+				t.Errorf("expected %q, got %q", input, stmt.String())
+			}
+		})
+	}
+}
+
+func TestParser_InvalidDistributions(t *testing.T) {
+	tests := []struct {
+		input string
+		msg   string
+	}{
+		{"float ~ gauss(0, 1) x;", "unknown distribution gauss"},
+		{"float ~ normal(0) x;", "normal takes 2 arguments (mean, stddev), got 1"},
+		{"string ~ normal(0, 1) x;", "string declarations cannot have a distribution"},
+		{"map ~ poisson(3)[string]int m;", "map declarations cannot have a distribution"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			l := NewLexer("test", tt.input)
+			p := NewParser(l)
+			p.ParseProgram()
+
+			if len(p.errors) != 1 {
+				t.Fatalf("expected 1 error, got %v", p.Errors())
+			}
+			if p.errors[0].Msg != tt.msg {
+				t.Errorf("expected %q, got %q", tt.msg, p.errors[0].Msg)
+			}
+		})
+	}
+}
+
 // ============================================================================
 // Parser Tests for Function Calls
 // ============================================================================
@@ -693,6 +945,1421 @@ func TestParser_OperatorMixing(t *testing.T) {
 	}
 }
 
+// ============================================================================
+// Parser Tests for Functions
+// ============================================================================
+
+func TestParser_FuncDeclaration(t *testing.T) {
+	input := `
+	func roll(int(1, 6) face, int bonus) int {
+		return face + bonus;
+	}
+	`
+	l := NewLexer("test", input)
+	p := NewParser(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	if len(program.Statements) != 1 {
+		t.Fatalf("expected 1 statement, got %d", len(program.Statements))
+	}
+
+	stmt, ok := program.Statements[0].(*FuncDecl)
+	if !ok {
+		t.Fatalf("statement is not FuncDecl, got %T", program.Statements[0])
+	}
+
+	if stmt.Name.Value != "roll" {
+		t.Errorf("expected name 'roll', got %s", stmt.Name.Value)
+	}
+
+	fn := stmt.Function
+	if len(fn.Parameters) != 2 {
+		t.Fatalf("expected 2 parameters, got %d", len(fn.Parameters))
+	}
+	if fn.Parameters[0].RangeMin == nil || fn.Parameters[0].RangeMax == nil {
+		t.Error("first parameter should have a range")
+	}
+	if fn.Parameters[1].Name.Value != "bonus" {
+		t.Errorf("expected second parameter 'bonus', got %s", fn.Parameters[1].Name.Value)
+	}
+	if fn.ReturnType != TYPE_INT {
+		t.Errorf("expected return type %s, got %s", TYPE_INT, fn.ReturnType)
+	}
+
+	ret, ok := fn.Body.Statements[0].(*ReturnStmt)
+	if !ok {
+		t.Fatalf("body statement is not ReturnStmt, got %T", fn.Body.Statements[0])
+	}
+	if ret.String() != "return (face + bonus);" {
+		t.Errorf("unexpected return statement: %s", ret.String())
+	}
+}
+
+func TestParser_FuncDeclarationWithoutReturnType(t *testing.T) {
+	l := NewLexer("test", "func greet() { print(\"hi\"); return; }")
+	p := NewParser(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	stmt, ok := program.Statements[0].(*FuncDecl)
+	if !ok {
+		t.Fatalf("statement is not FuncDecl, got %T", program.Statements[0])
+	}
+	if len(stmt.Function.Parameters) != 0 {
+		t.Errorf("expected no parameters, got %d", len(stmt.Function.Parameters))
+	}
+	if stmt.Function.ReturnType != "" {
+		t.Errorf("expected no return type, got %s", stmt.Function.ReturnType)
+	}
+
+	ret := stmt.Function.Body.Statements[1].(*ReturnStmt)
+	if ret.Value != nil {
+		t.Errorf("expected bare return, got %s", ret.Value.String())
+	}
+}
+
+func TestParser_FunctionLiterals(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{
+			"func_variable",
+			"func double = func(int x) int { return x * 2; };",
+			"func double = func(int x) int { return (x * 2); };",
+		},
+		{
+			"immediately_invoked",
+			"func(int x) { print(x); }(5);",
+			"func(int x) { print(x) }(5)",
+		},
+		{
+			"function_parameter",
+			"func apply(func f, int x) int { return f(x); }",
+			"func apply(func f, int x) int { return f(x); }",
+		},
+		{
+			"curried_call",
+			"adder(1)(2);",
+			"adder(1)(2)",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := NewLexer("test", tt.input)
+			p := NewParser(l)
+			program := p.ParseProgram()
+			checkParserErrors(t, p)
+
+			if len(program.Statements) != 1 {
+				t.Fatalf("expected 1 statement, got %d", len(program.Statements))
+			}
+
+			if str := program.Statements[0].String(); str != tt.expected {
+				t.Errorf("expected %q, got %q", tt.expected, str)
+			}
+		})
+	}
+}
+
+func TestParser_DuplicateParameters(t *testing.T) {
+	tests := []string{
+		"func f(int a, int a) { }",
+		"func f(int a, float b, string(1, 3) a) { }",
+		"func double = func(int x, int x) int { return x * 2; };",
+	}
+
+	for _, input := range tests {
+		t.Run(input, func(t *testing.T) {
+			l := NewLexer("test", input)
+			p := NewParser(l)
+			p.ParseProgram()
+
+			if len(p.errors) != 1 {
+				t.Fatalf("expected 1 error, got %v", p.Errors())
+			}
+			if msg := p.errors[0].Msg; !strings.HasPrefix(msg, "duplicate parameter ") {
+				t.Errorf("unexpected error %q", msg)
+			}
+		})
+	}
+}
+
+// ============================================================================
+// Parser Tests for Arrays
+// ============================================================================
+
+func TestParser_ArrayDeclarations(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{"sized", "int[5] rolls;", "int[5] rolls;"},
+		{"ranged_sized", "int(1, 6)[10] dice;", "int(1, 6)[10] dice;"},
+		{"unsized_literal", "float[] xs = [1, 2.5];", "float[] xs = [1, 2.5];"},
+		{"computed_size", "string[n + 1] names;", "string[(n + 1)] names;"},
+		{"func_array", "func[] fs = [f, g];", "func[] fs = [f, g];"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := NewLexer("test", tt.input)
+			p := NewParser(l)
+			program := p.ParseProgram()
+			checkParserErrors(t, p)
+
+			if len(program.Statements) != 1 {
+				t.Fatalf("expected 1 statement, got %d", len(program.Statements))
+			}
+
+			decl, ok := program.Statements[0].(*VarDecl)
+			if !ok {
+				t.Fatalf("expected *VarDecl, got %T", program.Statements[0])
+			}
+			if !decl.Array {
+				t.Error("expected an array declaration")
+			}
+			if str := decl.String(); str != tt.expected {
+				t.Errorf("expected %q, got %q", tt.expected, str)
+			}
+		})
+	}
+}
+
+func TestParser_IndexExpressions(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{"index", "xs[0];", "(xs[0])"},
+		{"index_binds_tighter", "xs[i] + 1;", "((xs[i]) + 1)"},
+		{"index_call_result", "f()[1];", "(f()[1])"},
+		{"nested", "grid[1][2];", "((grid[1])[2])"},
+		{"literal", "[1, 2, 3][i - 1];", "([1, 2, 3][(i - 1)])"},
+		{"assignment", "xs[i] = xs[i] * 2;", "xs[i] = ((xs[i]) * 2);"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := NewLexer("test", tt.input)
+			p := NewParser(l)
+			program := p.ParseProgram()
+			checkParserErrors(t, p)
+
+			if len(program.Statements) != 1 {
+				t.Fatalf("expected 1 statement, got %d", len(program.Statements))
+			}
+
+			if str := program.Statements[0].String(); str != tt.expected {
+				t.Errorf("expected %q, got %q", tt.expected, str)
+			}
+		})
+	}
+}
+
+func TestParser_ArrayFunctionSignature(t *testing.T) {
+	input := "func shuffle(int[] xs) int[] { return xs; }"
+	l := NewLexer("test", input)
+	p := NewParser(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	decl, ok := program.Statements[0].(*FuncDecl)
+	if !ok {
+		t.Fatalf("expected *FuncDecl, got %T", program.Statements[0])
+	}
+	if !decl.Function.Parameters[0].Array {
+		t.Error("expected parameter xs to be an array")
+	}
+	if !decl.Function.ReturnsArr {
+		t.Error("expected the function to return an array")
+	}
+	if str := decl.String(); str != input {
+		t.Errorf("expected %q, got %q", input, str)
+	}
+}
+
+// ============================================================================
+// Parser Tests for Maps
+// ============================================================================
+
+func TestParser_MapDeclarations(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+		key      TokenType
+		value    TokenType
+	}{
+		{"empty", "map[string]int scores;", "map[string]int scores;", TYPE_STRING, TYPE_INT},
+		{"sized", "map(1, 5)[int]bool seen;", "map(1, 5)[int]bool seen;", TYPE_INT, TYPE_BOOL},
+		{
+			"literal",
+			`map[string]float prices = {"tea": 2.5, "cake": 4};`,
+			`map[string]float prices = {"tea": 2.5, "cake": 4};`,
+			TYPE_STRING, TYPE_FLOAT,
+		},
+		{"func_values", "map[string]func ops = {};", "map[string]func ops = {};", TYPE_STRING, FUNC},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := NewLexer("test", tt.input)
+			p := NewParser(l)
+			program := p.ParseProgram()
+			checkParserErrors(t, p)
+
+			decl, ok := program.Statements[0].(*VarDecl)
+			if !ok {
+				t.Fatalf("expected *VarDecl, got %T", program.Statements[0])
+			}
+			if decl.KeyType != tt.key || decl.Type != tt.value {
+				t.Errorf("expected map[%s]%s, got map[%s]%s", tt.key, tt.value, decl.KeyType, decl.Type)
+			}
+			if str := decl.String(); str != tt.expected {
+				t.Errorf("expected %q, got %q", tt.expected, str)
+			}
+		})
+	}
+}
+
+func TestParser_MapExpressions(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{"index", `scores["bob"];`, `(scores["bob"])`},
+		{"assignment", `scores["bob"] = 3;`, `scores["bob"] = 3;`},
+		{"in", `"bob" in scores;`, `("bob" in scores)`},
+		{"in_binds_tighter_than_and", `"a" in m && "b" in m;`, `(("a" in m) && ("b" in m))`},
+		{"literal_argument", `print({1: "one", 2: "two"});`, `print({1: "one", 2: "two"})`},
+		{
+			"map_signature",
+			"func invert(map[string]int m) map[int]string { return {}; }",
+			"func invert(map[string]int m) map[int]string { return {}; }",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := NewLexer("test", tt.input)
+			p := NewParser(l)
+			program := p.ParseProgram()
+			checkParserErrors(t, p)
+
+			if len(program.Statements) != 1 {
+				t.Fatalf("expected 1 statement, got %d", len(program.Statements))
+			}
+			if str := program.Statements[0].String(); str != tt.expected {
+				t.Errorf("expected %q, got %q", tt.expected, str)
+			}
+		})
+	}
+}
+
+func TestParser_InvalidMapTypes(t *testing.T) {
+	tests := []string{
+		"map[func]int m;",
+		"map[string]map m;",
+		"map[string] m;",
+		"map string m;",
+	}
+
+	for _, input := range tests {
+		t.Run(input, func(t *testing.T) {
+			l := NewLexer("test", input)
+			p := NewParser(l)
+			p.ParseProgram()
+
+			if len(p.Errors()) == 0 {
+				t.Error("expected parser errors, got none")
+			}
+		})
+	}
+}
+
+// ============================================================================
+// Parser Tests for Structs
+// ============================================================================
+
+func TestParser_StructDeclaration(t *testing.T) {
+	input := "struct User { string name; int(18, 99) age; bool active; }"
+
+	l := NewLexer("test", input)
+	p := NewParser(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	decl, ok := program.Statements[0].(*StructDecl)
+	if !ok {
+		t.Fatalf("expected *StructDecl, got %T", program.Statements[0])
+	}
+	if decl.Name.Value != "User" {
+		t.Errorf("expected struct name User, got %s", decl.Name.Value)
+	}
+	if len(decl.Fields) != 3 {
+		t.Fatalf("expected 3 fields, got %d", len(decl.Fields))
+	}
+	if decl.Fields[1].RangeMin == nil || decl.Fields[1].Name.Value != "age" {
+		t.Errorf("expected ranged field age, got %s", decl.Fields[1].String())
+	}
+	if str := decl.String(); str != "struct User { string name; int(18, 99) age; bool active; }" {
+		t.Errorf("unexpected string: %q", str)
+	}
+}
+
+func TestParser_StructUsage(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{"declaration", "User u;", "User u;"},
+		{"array_declaration", "User[3] team;", "User[3] team;"},
+		{"field_access", "u.age;", "u.age"},
+		{"nested_field_access", "u.home.city;", "u.home.city"},
+		{"field_of_element", "team[0].age;", "(team[0]).age"},
+		{"field_assignment", "u.age = u.age + 1;", "u.age = (u.age + 1);"},
+		{"field_in_expression", "u.age * 2 > 10;", "((u.age * 2) > 10)"},
+		{"struct_signature", "func birthday(User u) User { return u; }", "func birthday(User u) User { return u; }"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := NewLexer("test", "struct User { int age; } "+tt.input)
+			p := NewParser(l)
+			program := p.ParseProgram()
+			checkParserErrors(t, p)
+
+			if len(program.Statements) != 2 {
+				t.Fatalf("expected 2 statements, got %d", len(program.Statements))
+			}
+			if str := program.Statements[1].String(); str != tt.expected {
+				t.Errorf("expected %q, got %q", tt.expected, str)
+			}
+		})
+	}
+}
+
+func TestParser_InvalidStructs(t *testing.T) {
+	tests := []string{
+		"struct { int x; }",
+		"struct User { x; }",
+		"struct User { int x; ",
+		"struct User { int x; } u.;",
+	}
+
+	for _, input := range tests {
+		t.Run(input, func(t *testing.T) {
+			l := NewLexer("test", input)
+			p := NewParser(l)
+			p.ParseProgram()
+
+			if len(p.Errors()) == 0 {
+				t.Error("expected parser errors, got none")
+			}
+		})
+	}
+}
+
+func TestParser_InvalidStructFields(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"struct P { int x; float y; int x; }", "duplicate field x in struct P"},
+		{"struct P { const int x = 1; string x; }", "duplicate field x in struct P"},
+		{"struct P { Q q; }", "unknown field type Q"},
+		{"struct P { Q[] qs; } int Q = 1;", "unknown field type Q"},
+		{"struct P { Color c; } enum Color { Red }", "type Color is used in a field before its declaration"},
+		{"struct P { Dice d; } type Dice = int(1, 7);", "type Dice is used in a field before its declaration"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			l := NewLexer("test", tt.input)
+			p := NewParser(l)
+			p.ParseProgram()
+
+			if len(p.errors) != 1 {
+				t.Fatalf("expected 1 parser error, got %d: %v", len(p.errors), p.Errors())
+			}
+			if msg := p.errors[0].Msg; msg != tt.expected {
+				t.Errorf("expected error %q, got %q", tt.expected, msg)
+			}
+		})
+	}
+}
+
+func TestParser_NestedStructDeclarations(t *testing.T) {
+	tests := []string{
+		"func mk() int { struct P { int(1, 3) a; } P p; return p.a; } mk(); mk();",
+		"repeat 2 { struct P { int a; } }",
+		"if (true) { struct P { int a; } } P p;",
+	}
+
+	for _, input := range tests {
+		t.Run(input, func(t *testing.T) {
+			l := NewLexer("test", input)
+			p := NewParser(l)
+			p.ParseProgram()
+
+			if len(p.errors) == 0 {
+				t.Fatal("expected parser errors, got none")
+			}
+			if msg := p.errors[0].Msg; msg != "struct P must be declared at the top level" {
+				t.Errorf("unexpected error %q", msg)
+			}
+		})
+	}
+}
+
+// ============================================================================
+// Parser Tests for Named Types
+// ============================================================================
+
+func TestParser_TypeDeclaration(t *testing.T) {
+	tests := []string{
+		"type Dice = int(1, 7);",
+		"type IQ = float(40, 160) ~ normal(100, 15);",
+		"type Hex = string(4, 5, \"0123456789abcdef\");",
+		"type Coin = bool(0.5);",
+		"type Id = int;",
+	}
+
+	for _, input := range tests {
+		t.Run(input, func(t *testing.T) {
+			l := NewLexer("test", input)
+			p := NewParser(l)
+			program := p.ParseProgram()
+			checkParserErrors(t, p)
+
+			decl, ok := program.Statements[0].(*TypeDecl)
+			if !ok {
+				t.Fatalf("expected *TypeDecl, got %T", program.Statements[0])
+			}
+			if str := decl.String(); str != input {
+				t.Errorf("expected %q, got %q", input, str)
+			}
+		})
+	}
+}
+
+func TestParser_NamedTypeUsage(t *testing.T) {
+	input := "type Dice = int(1, 7); Dice d; const Dice[3] rolls; func roll(Dice face) Dice { return face; }"
+
+	l := NewLexer("test", input)
+	p := NewParser(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	if len(program.Statements) != 4 {
+		t.Fatalf("expected 4 statements, got %d", len(program.Statements))
+	}
+
+	// The declaration takes the base type and range of the named type
+	d := program.Statements[1].(*VarDecl)
+	if d.Type != TYPE_INT || d.TypeName != "Dice" || d.RangeMin.String() != "1" || d.RangeMax.String() != "7" {
+		t.Errorf("unexpected declaration %+v", d)
+	}
+
+	expected := []string{"Dice d;", "const Dice[3] rolls;", "func roll(Dice face) int { return face; }"}
+	for idx, want := range expected {
+		if str := program.Statements[idx+1].String(); str != want {
+			t.Errorf("expected %q, got %q", want, str)
+		}
+	}
+}
+
+func TestParser_InvalidTypeDeclarations(t *testing.T) {
+	tests := []struct {
+		input string
+		msg   string
+	}{
+		{"type Dice = int(1, 7); type Dice = int(1, 3);", "type Dice is already declared"},
+		{"struct S { int x; } type S = int;", "type S is already declared"},
+		{"type D = Foo;", "expected int, uint, float, unofloat, bool or string as the base of type D, got Foo"},
+		{"type D = int(1, 7); D(1, 2) x;", "named type D already has its range and distribution"},
+		{"type T = int(1, 2)[3];", "type T cannot be an array, declare T[n] variables instead"},
+		{"if (false) { type Dice = int(1, 7); }", "type Dice must be declared at the top level"},
+		{"func f() { type Dice = int(1, 7); }", "type Dice must be declared at the top level"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			l := NewLexer("test", tt.input)
+			p := NewParser(l)
+			p.ParseProgram()
+
+			if len(p.errors) != 1 {
+				t.Fatalf("expected 1 error, got %v", p.Errors())
+			}
+			if p.errors[0].Msg != tt.msg {
+				t.Errorf("expected %q, got %q", tt.msg, p.errors[0].Msg)
+			}
+		})
+	}
+}
+
+// ============================================================================
+// Parser Tests for Enums
+// ============================================================================
+
+func TestParser_EnumDeclaration(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"enum Color { Red, Green, Blue }", "enum Color { Red, Green, Blue }"},
+		{"enum Status { Ok: 90, Error: 10, }", "enum Status { Ok: 90, Error: 10 }"},
+		{"enum Coin { Heads: 0.5 + 0.1, Tails: 0.4 }", "enum Coin { Heads: (0.5 + 0.1), Tails: 0.4 }"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			l := NewLexer("test", tt.input)
+			p := NewParser(l)
+			program := p.ParseProgram()
+			checkParserErrors(t, p)
+
+			decl, ok := program.Statements[0].(*EnumDecl)
+			if !ok {
+				t.Fatalf("expected *EnumDecl, got %T", program.Statements[0])
+			}
+			if str := decl.String(); str != tt.expected {
+				t.Errorf("expected %q, got %q", tt.expected, str)
+			}
+		})
+	}
+}
+
+func TestParser_EnumUsage(t *testing.T) {
+	input := `enum Color { Red, Green }
+	Color c;
+	Color[2] pair;
+	func pick(Color fallback) Color { return fallback; }
+	Color.Red == c;
+	match c { Color.Red => { print("red"); }, _ => { print("green"); } }`
+
+	l := NewLexer("test", input)
+	p := NewParser(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	if len(program.Statements) != 6 {
+		t.Fatalf("expected 6 statements, got %d", len(program.Statements))
+	}
+
+	c := program.Statements[1].(*VarDecl)
+	if c.Type != ENUM || c.TypeName != "Color" {
+		t.Errorf("expected an ENUM declaration of Color, got %s %q", c.Type, c.TypeName)
+	}
+
+	fn := program.Statements[3].(*FuncDecl).Function
+	if fn.ReturnType != ENUM || fn.ReturnName != "Color" {
+		t.Errorf("expected an ENUM return type Color, got %s %q", fn.ReturnType, fn.ReturnName)
+	}
+
+	expected := []string{
+		"Color c;",
+		"Color[2] pair;",
+		"func pick(Color fallback) Color { return fallback; }",
+		"(Color.Red == c)",
+	}
+	for idx, want := range expected {
+		if str := program.Statements[idx+1].String(); str != want {
+			t.Errorf("expected %q, got %q", want, str)
+		}
+	}
+}
+
+func TestParser_InvalidEnumDeclarations(t *testing.T) {
+	tests := []struct {
+		input string
+		msg   string
+	}{
+		{"enum Empty { }", "enum Empty needs at least one member"},
+		{"enum Color { Red, Red }", "enum Color already has a member Red"},
+		{"enum Status { Ok: 90, Error }", "enum Status must give a weight to every member or to none"},
+		{"enum Color { Red } enum Color { Blue }", "type Color is already declared"},
+		{"struct Color { int x; } enum Color { Red }", "type Color is already declared"},
+		{"enum Color { Red } type Color = int;", "type Color is already declared"},
+		{"enum Color { Red } Color(1, 2) c;", "enum Color does not take a range or distribution"},
+		{"repeat 2 { enum E { A, B } E e; }", "enum E must be declared at the top level"},
+		{"func f() { enum E { A } }", "enum E must be declared at the top level"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			l := NewLexer("test", tt.input)
+			p := NewParser(l)
+			p.ParseProgram()
+
+			if len(p.errors) != 1 {
+				t.Fatalf("expected 1 error, got %v", p.Errors())
+			}
+			if p.errors[0].Msg != tt.msg {
+				t.Errorf("expected %q, got %q", tt.msg, p.errors[0].Msg)
+			}
+		})
+	}
+}
+
+// ============================================================================
+// Parser Tests for Constants
+// ============================================================================
+
+func TestParser_ConstDeclarations(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{"ranged", "const int(1, 6) ROLL;", "const int(1, 6) ROLL;"},
+		{"initialized", "const float PI = 3.14;", "const float PI = 3.14;"},
+		{"array", "const int[] PRIMES = [2, 3, 5];", "const int[] PRIMES = [2, 3, 5];"},
+		{"map", `const map[string]int LIMITS = {"a": 1};`, `const map[string]int LIMITS = {"a": 1};`},
+		{"func", "const func twice = func(int x) int { return x * 2; };", "const func twice = func(int x) int { return (x * 2); };"},
+		{"struct_field", "struct P { const int id; int x; }", "struct P { const int id; int x; }"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := NewLexer("test", tt.input)
+			p := NewParser(l)
+			program := p.ParseProgram()
+			checkParserErrors(t, p)
+
+			if len(program.Statements) != 1 {
+				t.Fatalf("expected 1 statement, got %d", len(program.Statements))
+			}
+			if decl, ok := program.Statements[0].(*VarDecl); ok && !decl.Const {
+				t.Error("expected a const declaration")
+			}
+			if str := program.Statements[0].String(); str != tt.expected {
+				t.Errorf("expected %q, got %q", tt.expected, str)
+			}
+		})
+	}
+}
+
+func TestParser_InvalidConstDeclarations(t *testing.T) {
+	tests := []string{
+		"const x = 1;",
+		"const;",
+		"const const int x = 1;",
+	}
+
+	for _, input := range tests {
+		t.Run(input, func(t *testing.T) {
+			l := NewLexer("test", input)
+			p := NewParser(l)
+			p.ParseProgram()
+
+			if len(p.Errors()) == 0 {
+				t.Error("expected parser errors, got none")
+			}
+		})
+	}
+}
+
+// ============================================================================
+// Parser Tests for Imports
+// ============================================================================
+
+func TestParser_ImportStatement(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+		alias    string
+	}{
+		{"default_name", `import "dice.wtf";`, `import "dice.wtf";`, ""},
+		{"alias", `import "lib/dice.wtf" as d;`, `import "lib/dice.wtf" as d;`, "d"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := NewLexer("test", tt.input)
+			p := NewParser(l)
+			program := p.ParseProgram()
+			checkParserErrors(t, p)
+
+			stmt, ok := program.Statements[0].(*ImportStmt)
+			if !ok {
+				t.Fatalf("expected *ImportStmt, got %T", program.Statements[0])
+			}
+			if (stmt.Alias == nil && tt.alias != "") || (stmt.Alias != nil && stmt.Alias.Value != tt.alias) {
+				t.Errorf("expected alias %q, got %v", tt.alias, stmt.Alias)
+			}
+			if str := stmt.String(); str != tt.expected {
+				t.Errorf("expected %q, got %q", tt.expected, str)
+			}
+		})
+	}
+}
+
+func TestParser_InvalidImports(t *testing.T) {
+	tests := []string{
+		"import dice;",
+		`import "dice.wtf" as;`,
+		`import "dice.wtf" as "d";`,
+	}
+
+	for _, input := range tests {
+		t.Run(input, func(t *testing.T) {
+			l := NewLexer("test", input)
+			p := NewParser(l)
+			p.ParseProgram()
+
+			if len(p.Errors()) == 0 {
+				t.Error("expected parser errors, got none")
+			}
+		})
+	}
+}
+
+func TestParser_ErrorsCarryFileName(t *testing.T) {
+	l := NewLexer("scripts/main.wtf", "int x = 1;\nint = 2;")
+	p := NewParser(l)
+	p.ParseProgram()
+
+	if len(p.errors) == 0 {
+		t.Fatal("expected parser errors, got none")
+	}
+	err := p.errors[0]
+	if err.File != "scripts/main.wtf" || err.Line != 2 {
+		t.Errorf("expected error in scripts/main.wtf on line 2, got %s line %d", err.File, err.Line)
+	}
+	if !strings.HasPrefix(err.Error(), "[scripts/main.wtf, Line 2, Col 5]") {
+		t.Errorf("expected the file name in the message, got %q", err.Error())
+	}
+}
+
+// ============================================================================
+// Parser Tests for Match
+// ============================================================================
+
+func TestParser_MatchStatement(t *testing.T) {
+	input := `
+	match score {
+		90..100 => { print("A"); },
+		"x" => { print("X"); },
+		-5 => { print("negative"); },
+		_ => { print("F"); },
+	}
+	`
+
+	l := NewLexer("test", input)
+	p := NewParser(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	stmt, ok := program.Statements[0].(*MatchStmt)
+	if !ok {
+		t.Fatalf("expected *MatchStmt, got %T", program.Statements[0])
+	}
+	if len(stmt.Arms) != 4 {
+		t.Fatalf("expected 4 arms, got %d", len(stmt.Arms))
+	}
+	if _, ok := stmt.Arms[0].Pattern.(*RangeExpr); !ok {
+		t.Errorf("expected a range pattern, got %T", stmt.Arms[0].Pattern)
+	}
+	if stmt.Arms[3].Pattern != nil {
+		t.Errorf("expected the default arm, got %s", stmt.Arms[3].Pattern.String())
+	}
+
+	expected := `match score { (90..100) => { print("A") }, "x" => { print("X") }, -5 => { print("negative") }, _ => { print("F") } }`
+	if str := stmt.String(); str != expected {
+		t.Errorf("expected %q, got %q", expected, str)
+	}
+	if len(p.Warnings()) != 0 {
+		t.Errorf("expected no warnings, got %v", p.Warnings())
+	}
+}
+
+func TestParser_MatchWithoutDefaultWarns(t *testing.T) {
+	l := NewLexer("test", "int x = 1;\nmatch x { 1 => { } }")
+	p := NewParser(l)
+	p.ParseProgram()
+	checkParserErrors(t, p)
+
+	if len(p.warnings) != 1 {
+		t.Fatalf("expected 1 warning, got %d", len(p.warnings))
+	}
+	if w := p.warnings[0]; w.Line != 2 || w.Column != 1 {
+		t.Errorf("expected the warning at 2:1, got %d:%d", w.Line, w.Column)
+	}
+}
+
+func TestParser_InvalidMatch(t *testing.T) {
+	tests := []string{
+		"match x { y => { } }",
+		"match x { 1 + 2 => { } }",
+		"match x { int(1, 5) => { } }",
+		"match x { 1..2..3 => { } }",
+		"match x { 1 { } }",
+		"match x { 1 => print(1); }",
+		"match x { _ => { }, 1 => { } }",
+		"match x { 1 => { }",
+	}
+
+	for _, input := range tests {
+		t.Run(input, func(t *testing.T) {
+			l := NewLexer("test", input)
+			p := NewParser(l)
+			p.ParseProgram()
+
+			if len(p.Errors()) == 0 {
+				t.Error("expected parser errors, got none")
+			}
+		})
+	}
+}
+
+func TestParser_InvalidMatchArmReportedOnce(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`match x { y => { print(1); }, 2 => { print(2); } } print(3);`,
+			"invalid match pattern, expected a literal, an enum member, a range a..b or _"},
+		{`match x { 1 => { print(1); } 2 => { print(2); } } print(3);`,
+			"expected , or } after a match arm, got INT"},
+		{`match x { 1 { if (true) { print(1); } }, 2 => { } } print(3);`,
+			"expected next token to be =>, got { instead"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			l := NewLexer("test", tt.input)
+			p := NewParser(l)
+			p.ParseProgram()
+
+			if len(p.errors) != 1 {
+				t.Fatalf("expected 1 parser error, got %d: %v", len(p.errors), p.Errors())
+			}
+			if p.errors[0].Msg != tt.expected {
+				t.Errorf("expected error %q, got %q", tt.expected, p.errors[0].Msg)
+			}
+		})
+	}
+}
+
+func TestParser_IfrandExpression(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`string s = ifrand(0.3) ? "rare" : "common";`, `string s = (ifrand(0.3) ? "rare" : "common");`},
+		{"int x = ifrand ? 1 : 2;", "int x = (ifrand ? 1 : 2);"},
+		{"print(ifrand(p * 2) ? a : b);", "print((ifrand((p * 2)) ? a : b))"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			l := NewLexer("test", tt.input)
+			p := NewParser(l)
+			program := p.ParseProgram()
+			checkParserErrors(t, p)
+
+			if str := program.String(); str != tt.expected {
+				t.Errorf("expected %q, got %q", tt.expected, str)
+			}
+		})
+	}
+}
+
+func TestParser_InvalidConditionalExpression(t *testing.T) {
+	tests := []string{
+		"int x = a ? b;",
+		"int x = a ? b c;",
+		"int x = a ? : c;",
+		"int x = ifrand(0.5) a : b;",
+		"int x = ifrand(0.5;",
+	}
+
+	for _, input := range tests {
+		t.Run(input, func(t *testing.T) {
+			l := NewLexer("test", input)
+			p := NewParser(l)
+			p.ParseProgram()
+
+			if len(p.Errors()) == 0 {
+				t.Error("expected parser errors, got none")
+			}
+		})
+	}
+}
+
+func TestParser_ChooseStatement(t *testing.T) {
+	input := `
+	choose {
+		3 => { print("a"); },
+		0.5 => { print("b"); }
+		w * 2 => { print("c"); },
+	}
+	`
+
+	l := NewLexer("test", input)
+	p := NewParser(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	stmt, ok := program.Statements[0].(*ChooseStmt)
+	if !ok {
+		t.Fatalf("expected *ChooseStmt, got %T", program.Statements[0])
+	}
+	if len(stmt.Arms) != 3 {
+		t.Fatalf("expected 3 arms, got %d", len(stmt.Arms))
+	}
+
+	expected := `choose { 3 => { print("a") }, 0.5 => { print("b") }, (w * 2) => { print("c") } }`
+	if str := stmt.String(); str != expected {
+		t.Errorf("expected %q, got %q", expected, str)
+	}
+}
+
+func TestParser_InvalidChoose(t *testing.T) {
+	tests := []string{
+		"choose { }",
+		"choose { 1 { } }",
+		"choose { 1 => print(1); }",
+		"choose { 1 => { }",
+		"choose 1 => { }",
+	}
+
+	for _, input := range tests {
+		t.Run(input, func(t *testing.T) {
+			l := NewLexer("test", input)
+			p := NewParser(l)
+			p.ParseProgram()
+
+			if len(p.Errors()) == 0 {
+				t.Error("expected parser errors, got none")
+			}
+		})
+	}
+}
+
+func TestParser_TryStatement(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`try { int x = 1 / d; } catch (err) { print(err.message); }`, "try int x = (1 / d); catch (err) print(err.message)"},
+		{`try { throw "boom"; } catch { }`, `try throw "boom"; catch `},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			l := NewLexer("test", tt.input)
+			p := NewParser(l)
+			program := p.ParseProgram()
+			checkParserErrors(t, p)
+
+			if _, ok := program.Statements[0].(*TryStmt); !ok {
+				t.Fatalf("expected *TryStmt, got %T", program.Statements[0])
+			}
+			if str := program.String(); str != tt.expected {
+				t.Errorf("expected %q, got %q", tt.expected, str)
+			}
+		})
+	}
+}
+
+func TestParser_InvalidTry(t *testing.T) {
+	tests := []string{
+		"try { }",
+		"try print(1); catch { }",
+		"try { } catch (1) { }",
+		"try { } catch (err { }",
+		"try { } catch (err) print(err);",
+		"throw;",
+	}
+
+	for _, input := range tests {
+		t.Run(input, func(t *testing.T) {
+			l := NewLexer("test", input)
+			p := NewParser(l)
+			p.ParseProgram()
+
+			if len(p.Errors()) == 0 {
+				t.Error("expected parser errors, got none")
+			}
+		})
+	}
+}
+
+func TestParser_ReservedErrorType(t *testing.T) {
+	tests := []string{
+		"struct Error { int code; }",
+		"enum Error { Bad }",
+		"type Error = int(1, 7);",
+	}
+
+	for _, input := range tests {
+		t.Run(input, func(t *testing.T) {
+			l := NewLexer("test", input)
+			p := NewParser(l)
+			p.ParseProgram()
+
+			if len(p.errors) != 1 {
+				t.Fatalf("expected 1 error, got %v", p.Errors())
+			}
+			if msg := p.errors[0].Msg; msg != "type name Error is reserved for caught errors" {
+				t.Errorf("unexpected error %q", msg)
+			}
+		})
+	}
+}
+
+func TestParser_AssertStatement(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`assert(x > 0, "x must be positive");`, `assert((x > 0), "x must be positive");`},
+		{"assert(ok)", "assert(ok);"},
+		{`assert(a == b && c, "msg: " + s);`, `assert(((a == b) && c), ("msg: " + s));`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			l := NewLexer("test", tt.input)
+			p := NewParser(l)
+			program := p.ParseProgram()
+			checkParserErrors(t, p)
+
+			if _, ok := program.Statements[0].(*AssertStmt); !ok {
+				t.Fatalf("expected *AssertStmt, got %T", program.Statements[0])
+			}
+			if str := program.String(); str != tt.expected {
+				t.Errorf("expected %q, got %q", tt.expected, str)
+			}
+		})
+	}
+}
+
+func TestParser_InvalidAssert(t *testing.T) {
+	tests := []string{
+		"assert x > 0;",
+		"assert();",
+		"assert(x > 0;",
+		"assert(x > 0, );",
+		`assert(x, "a", "b");`,
+	}
+
+	for _, input := range tests {
+		t.Run(input, func(t *testing.T) {
+			l := NewLexer("test", input)
+			p := NewParser(l)
+			p.ParseProgram()
+
+			if len(p.Errors()) == 0 {
+				t.Error("expected parser errors, got none")
+			}
+		})
+	}
+}
+
+func TestParser_RerollStatement(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+		names    int
+	}{
+		{"reroll d;", "reroll d;", 1},
+		{"reroll a, b", "reroll a, b;", 2},
+		{"reroll all;", "reroll all;", 0},
+		{"reroll all, d;", "reroll all, d;", 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			l := NewLexer("test", tt.input)
+			p := NewParser(l)
+			program := p.ParseProgram()
+			checkParserErrors(t, p)
+
+			stmt, ok := program.Statements[0].(*RerollStmt)
+			if !ok {
+				t.Fatalf("expected *RerollStmt, got %T", program.Statements[0])
+			}
+			if len(stmt.Names) != tt.names {
+				t.Errorf("expected %d names, got %d", tt.names, len(stmt.Names))
+			}
+			if str := program.String(); str != tt.expected {
+				t.Errorf("expected %q, got %q", tt.expected, str)
+			}
+		})
+	}
+}
+
+func TestParser_InvalidReroll(t *testing.T) {
+	tests := []string{
+		"reroll;",
+		"reroll 5;",
+		"reroll a,;",
+	}
+
+	for _, input := range tests {
+		t.Run(input, func(t *testing.T) {
+			l := NewLexer("test", input)
+			p := NewParser(l)
+			p.ParseProgram()
+
+			if len(p.Errors()) == 0 {
+				t.Error("expected parser errors, got none")
+			}
+		})
+	}
+}
+
+func TestParser_VolatileDeclarations(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"volatile int(1, 6) die;", "volatile int(1, 6) die;"},
+		{"volatile float ~ normal(0, 1) noise;", "volatile float ~ normal(0, 1) noise;"},
+		{"volatile bool coin;", "volatile bool coin;"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			l := NewLexer("test", tt.input)
+			p := NewParser(l)
+			program := p.ParseProgram()
+			checkParserErrors(t, p)
+
+			decl, ok := program.Statements[0].(*VarDecl)
+			if !ok || !decl.Volatile {
+				t.Fatalf("expected a volatile declaration, got %T", program.Statements[0])
+			}
+			if str := decl.String(); str != tt.expected {
+				t.Errorf("expected %q, got %q", tt.expected, str)
+			}
+		})
+	}
+}
+
+func TestParser_InvalidVolatileDeclarations(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"volatile x;", "expected type after volatile, got IDENT"},
+		{"volatile int(1, 6) die = 3;", "volatile variable die is drawn on every read and cannot have a value"},
+		{"volatile int[3] dice;", "volatile variable dice must be a single random value, not an array, map, struct or func"},
+		{"volatile map[string]int m;", "volatile variable m must be a single random value, not an array, map, struct or func"},
+		{"struct P { int x; } volatile P p;", "volatile variable p must be a single random value, not an array, map, struct or func"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			l := NewLexer("test", tt.input)
+			p := NewParser(l)
+			p.ParseProgram()
+
+			if len(p.errors) == 0 {
+				t.Fatal("expected parser errors, got none")
+			}
+			if p.errors[0].Msg != tt.expected {
+				t.Errorf("expected %q, got %q", tt.expected, p.errors[0].Msg)
+			}
+		})
+	}
+}
+
+func TestParser_StringLiteralValues(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`"plain"`, "plain"},
+		{`"a\tb\nc\rd"`, "a\tb\nc\rd"},
+		{`"say \"hi\" \\ \$5"`, `say "hi" \ $5`},
+		{`"\u{41}\u{1F3B2}\u{e9}"`, "A🎲é"},
+		{"`raw \\n ${x} \\`", `raw \n ${x} \`},
+		{`"""multi "quoted" \t"""`, "multi \"quoted\" \t"},
+		{"\"\"\"\nfirst\n  second\n\"\"\"", "first\n  second\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			l := NewLexer("test", tt.input+";")
+			p := NewParser(l)
+			program := p.ParseProgram()
+			checkParserErrors(t, p)
+
+			lit, ok := program.Statements[0].(*ExprStmt).Expression.(*StringLiteral)
+			if !ok {
+				t.Fatalf("expected *StringLiteral, got %T", program.Statements[0].(*ExprStmt).Expression)
+			}
+			if lit.Value != tt.expected {
+				t.Errorf("expected %q, got %q", tt.expected, lit.Value)
+			}
+			if lit.String() != tt.input {
+				t.Errorf("expected the source text %s, got %s", tt.input, lit.String())
+			}
+		})
+	}
+}
+
+func TestParser_NumericLiteralValues(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected any
+	}{
+		{"0xFF", int64(255)},
+		{"0b1010", int64(10)},
+		{"0o17", int64(15)},
+		{"017", int64(17)},
+		{"-0x10", int64(-16)},
+		{"1_000_000", int64(1000000)},
+		{"1e3", 1000.0},
+		{"2.5e-1", 0.25},
+		{".5", 0.5},
+		{"5u", uint64(5)},
+		{"0xFFFF_FFFF_FFFF_FFFFu", uint64(18446744073709551615)},
+		{"30%", 0.3},
+		{"100%", 1.0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			l := NewLexer("test", tt.input+";")
+			p := NewParser(l)
+			program := p.ParseProgram()
+			checkParserErrors(t, p)
+
+			var value any
+			switch lit := program.Statements[0].(*ExprStmt).Expression.(type) {
+			case *IntegerLiteral:
+				value = lit.Value
+			case *FloatLiteral:
+				value = lit.Value
+			case *UintLiteral:
+				value = lit.Value
+			case *PercentLiteral:
+				value = lit.Value
+			default:
+				t.Fatalf("unexpected literal %T", lit)
+			}
+			if value != tt.expected {
+				t.Errorf("expected %v (%T), got %v (%T)", tt.expected, tt.expected, value, value)
+			}
+		})
+	}
+}
+
+func TestParser_InvalidNumericLiterals(t *testing.T) {
+	tests := []struct {
+		input string
+		msg   string
+	}{
+		{"9223372036854775808", `could not parse "9223372036854775808" as integer`},
+		{"-5u", `could not parse "-5u" as uint`},
+		{"150%", `could not parse "150%" as percent, it must be between 0% and 100%`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			l := NewLexer("test", "x = "+tt.input+";")
+			p := NewParser(l)
+			p.ParseProgram()
+
+			if len(p.errors) != 1 {
+				t.Fatalf("expected 1 error, got %v", p.Errors())
+			}
+			if err := p.errors[0]; err.Msg != tt.msg || err.Column != 5 {
+				t.Errorf("unexpected error %q at column %d", err.Msg, err.Column)
+			}
+		})
+	}
+}
+
+func TestParser_InvalidEscapeReported(t *testing.T) {
+	l := NewLexer("test", "string s = \"a\\qb\";\nint x = 1;")
+	p := NewParser(l)
+	p.ParseProgram()
+
+	if len(p.errors) != 1 {
+		t.Fatalf("expected 1 error, got %v", p.Errors())
+	}
+	if err := p.errors[0]; err.Msg != `Illegal token: invalid escape sequence \q` || err.Column != 14 {
+		t.Errorf("unexpected error %q at column %d", err.Msg, err.Column)
+	}
+}
+
+func TestParser_TemplateString(t *testing.T) {
+	input := `string s = "rolled ${dice} on try ${i + 1}!";`
+
+	l := NewLexer("test", input)
+	p := NewParser(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	decl := program.Statements[0].(*VarDecl)
+	template, ok := decl.Value.(*TemplateString)
+	if !ok {
+		t.Fatalf("expected *TemplateString, got %T", decl.Value)
+	}
+
+	expected := []string{`"rolled "`, "dice", `" on try "`, "(i + 1)", `"!"`}
+	if len(template.Parts) != len(expected) {
+		t.Fatalf("expected %d parts, got %d", len(expected), len(template.Parts))
+	}
+	for idx, want := range expected {
+		if got := template.Parts[idx].String(); got != want {
+			t.Errorf("part %d: expected %s, got %s", idx, want, got)
+		}
+	}
+
+	// Embedded expressions keep their position in the file
+	ident := template.Parts[1].(*Identifier)
+	if ident.Token.Line != 1 || ident.Token.Column != 22 {
+		t.Errorf("expected dice at 1:22, got %d:%d", ident.Token.Line, ident.Token.Column)
+	}
+	if str := template.String(); str != `"rolled ${dice} on try ${i + 1}!"` {
+		t.Errorf("unexpected source text %s", str)
+	}
+}
+
+func TestParser_InvalidTemplateString(t *testing.T) {
+	tests := []struct {
+		input  string
+		column int
+	}{
+		{`print("${}");`, 10},
+		{`print("a ${1 2}");`, 14},
+		{`print("a ${x +}");`, 15},
+		{`print("${int x}");`, 14},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			l := NewLexer("test", tt.input)
+			p := NewParser(l)
+			p.ParseProgram()
+
+			if len(p.errors) == 0 {
+				t.Fatal("expected parser errors, got none")
+			}
+			if col := p.errors[0].Column; col != tt.column {
+				t.Errorf("expected the error at column %d, got %d", tt.column, col)
+			}
+		})
+	}
+}
+
 // ============================================================================
 // Parser Error Tests
 // ============================================================================
@@ -0,0 +1,59 @@
+package interpreter
+
+// evalRerollStmt draws a fresh value for every named variable from the generator of its
+// declaration, as if it was declared again with the same range and distribution.
+// reroll all; does so for every variable in scope that was drawn at random.
+func (i *Interpreter) evalRerollStmt(node *RerollStmt) (any, error) {
+	if node.Names == nil {
+		return nil, i.rerollAll()
+	}
+
+	for _, name := range node.Names {
+		v, ok := i.env.Get(name.Value)
+		if !ok {
+			return nil, NewVariableNotDefinedError(name)
+		}
+		if v.Const {
+			return nil, NewConstRerollError(name)
+		}
+		if v.Generator == nil {
+			return nil, NewRuntimeError(name.Token.Position(),
+				"cannot reroll %s: it was not drawn at random when it was declared", name.Value)
+		}
+
+		val, err := v.Generator()
+		if err != nil {
+			return nil, err
+		}
+		v.Value = val
+		i.env.Set(name.Value, v)
+	}
+	return nil, nil
+}
+
+// rerollAll rerolls the variables visible from the current scope, innermost scope first and
+// in declaration order within a scope. Constants, shadowed variables and variables that were
+// not drawn at random, such as int count = 0;, keep their values.
+func (i *Interpreter) rerollAll() error {
+	seen := make(map[string]bool)
+	for env := i.env; env != nil; env = env.outer {
+		for _, name := range env.names {
+			if seen[name] {
+				continue
+			}
+			seen[name] = true
+
+			v := env.store[name]
+			if v.Const || v.Generator == nil {
+				continue
+			}
+			val, err := v.Generator()
+			if err != nil {
+				return err
+			}
+			v.Value = val
+			env.store[name] = v
+		}
+	}
+	return nil
+}
@@ -0,0 +1,31 @@
+package interpreter
+
+// Control flow signals are propagated through Evaluate as errors so that every
+// statement between the signal and the construct handling it unwinds untouched.
+// If a signal escapes to the top level it is reported like any other runtime error.
+
+type breakSignal struct {
+	*Position
+}
+
+func (s *breakSignal) Error() string {
+	return PrintError(s.Position, "runtime", "break outside of loop")
+}
+
+type continueSignal struct {
+	*Position
+}
+
+func (s *continueSignal) Error() string {
+	return PrintError(s.Position, "runtime", "continue outside of loop")
+}
+
+type returnSignal struct {
+	*Position
+	Value  any
+	Strict bool // whether the returned expression is a literal or variable, see validateUintAssignment
+}
+
+func (s *returnSignal) Error() string {
+	return PrintError(s.Position, "runtime", "return outside of function")
+}
@@ -0,0 +1,18 @@
+package interpreter
+
+// structType is the runtime definition of a struct declaration.
+// Field initializers are evaluated in the scope the struct was declared in.
+type structType struct {
+	Decl *StructDecl
+	Env  *Environment
+}
+
+// field returns the declaration of a field, or nil if the struct has no such field
+func (s *structType) field(name string) *VarDecl {
+	for _, f := range s.Decl.Fields {
+		if f.Name.Value == name {
+			return f
+		}
+	}
+	return nil
+}
@@ -6,10 +6,16 @@ type TokenType string
 type Token struct {
 	Type    TokenType
 	Literal string // the actual text
+	File    string // name of the source file, empty for code that does not come from a file
 	Line    int    // line number for error messages
 	Column  int    // column number for error messages
 }
 
+// Position returns the source position of the token, used for error messages
+func (t Token) Position() *Position {
+	return &Position{File: t.File, Line: t.Line, Column: t.Column}
+}
+
 const (
 	// Special tokens
 	ILLEGAL TokenType = "ILLEGAL"
@@ -17,12 +23,15 @@ const (
 	COMMENT TokenType = "COMMENT"
 
 	// Identifiers and literals
-	IDENT  TokenType = "IDENT"
-	INT    TokenType = "INT"
-	FLOAT  TokenType = "FLOAT"
-	STRING TokenType = "STRING"
-	TRUE   TokenType = "TRUE"
-	FALSE  TokenType = "FALSE"
+	IDENT    TokenType = "IDENT"
+	INT      TokenType = "INT"
+	UINT     TokenType = "UINT" // an integer with the u suffix, such as 5u
+	FLOAT    TokenType = "FLOAT"
+	PERCENT  TokenType = "PERCENT" // a unofloat written as a percentage, such as 30%
+	STRING   TokenType = "STRING"
+	TEMPLATE TokenType = "TEMPLATE" // a string literal with ${...} interpolations
+	TRUE     TokenType = "TRUE"
+	FALSE    TokenType = "FALSE"
 
 	// Operators
 	ASSIGN   TokenType = "="
@@ -30,6 +39,9 @@ const (
 	MINUS    TokenType = "-"
 	ASTERISK TokenType = "*"
 	SLASH    TokenType = "/"
+	ARROW    TokenType = "=>"
+	QUESTION TokenType = "?"
+	TILDE    TokenType = "~"
 
 	// Comparison operators
 	EQ   TokenType = "=="
@@ -47,10 +59,15 @@ const (
 	// Delimiters
 	SEMICOLON TokenType = ";"
 	COMMA     TokenType = ","
+	COLON     TokenType = ":"
 	LPAREN    TokenType = "("
 	RPAREN    TokenType = ")"
 	LBRACE    TokenType = "{"
 	RBRACE    TokenType = "}"
+	LBRACKET  TokenType = "["
+	RBRACKET  TokenType = "]"
+	DOTDOT    TokenType = ".."
+	DOT       TokenType = "."
 
 	// Type keywords
 	TYPE_INT      TokenType = "INT_TYPE"
@@ -59,26 +76,102 @@ const (
 	TYPE_UNOFLOAT TokenType = "UNOFLOAT_TYPE"
 	TYPE_BOOL     TokenType = "BOOL_TYPE"
 	TYPE_STRING   TokenType = "STRING_TYPE"
+	TYPE_MAP      TokenType = "MAP_TYPE"
 
 	// Control flow keywords
 	IF     TokenType = "IF"
 	ELSE   TokenType = "ELSE"
 	IFRAND TokenType = "IFRAND"
+
+	// Loop keywords
+	WHILE    TokenType = "WHILE"
+	BREAK    TokenType = "BREAK"
+	CONTINUE TokenType = "CONTINUE"
+	FOR      TokenType = "FOR"
+	IN       TokenType = "IN"
+
+	// Random loop keywords
+	REPEAT     TokenType = "REPEAT"
+	REPEATRAND TokenType = "REPEATRAND"
+	WHILERAND  TokenType = "WHILERAND"
+
+	// Function keywords
+	FUNC   TokenType = "FUNC"
+	RETURN TokenType = "RETURN"
+
+	// Struct keyword
+	STRUCT TokenType = "STRUCT"
+
+	// Named type keyword
+	TYPE TokenType = "TYPE"
+
+	// Enum keyword, also the type of declarations such as Color c;
+	ENUM TokenType = "ENUM"
+
+	// Declaration modifiers
+	CONST    TokenType = "CONST"
+	VOLATILE TokenType = "VOLATILE"
+
+	// Module keywords
+	IMPORT TokenType = "IMPORT"
+	AS     TokenType = "AS"
+
+	// Match keyword
+	MATCH TokenType = "MATCH"
+
+	// Weighted random choice keyword
+	CHOOSE TokenType = "CHOOSE"
+
+	// Error handling keywords
+	TRY   TokenType = "TRY"
+	CATCH TokenType = "CATCH"
+	THROW TokenType = "THROW"
+
+	// Assertion keyword
+	ASSERT TokenType = "ASSERT"
+
+	// Re-sampling keyword
+	REROLL TokenType = "REROLL"
 )
 
 // keywords maps keyword strings to their TokenType
 var keywords = map[string]TokenType{
-	"int":      TYPE_INT,
-	"uint":     TYPE_UINT,
-	"float":    TYPE_FLOAT,
-	"unofloat": TYPE_UNOFLOAT,
-	"bool":     TYPE_BOOL,
-	"string":   TYPE_STRING,
-	"true":     TRUE,
-	"false":    FALSE,
-	"if":       IF,
-	"else":     ELSE,
-	"ifrand":   IFRAND,
+	"int":        TYPE_INT,
+	"uint":       TYPE_UINT,
+	"float":      TYPE_FLOAT,
+	"unofloat":   TYPE_UNOFLOAT,
+	"bool":       TYPE_BOOL,
+	"string":     TYPE_STRING,
+	"map":        TYPE_MAP,
+	"true":       TRUE,
+	"false":      FALSE,
+	"if":         IF,
+	"else":       ELSE,
+	"ifrand":     IFRAND,
+	"while":      WHILE,
+	"break":      BREAK,
+	"continue":   CONTINUE,
+	"for":        FOR,
+	"in":         IN,
+	"repeat":     REPEAT,
+	"repeatrand": REPEATRAND,
+	"whilerand":  WHILERAND,
+	"func":       FUNC,
+	"return":     RETURN,
+	"struct":     STRUCT,
+	"type":       TYPE,
+	"enum":       ENUM,
+	"const":      CONST,
+	"volatile":   VOLATILE,
+	"import":     IMPORT,
+	"as":         AS,
+	"match":      MATCH,
+	"choose":     CHOOSE,
+	"try":        TRY,
+	"catch":      CATCH,
+	"throw":      THROW,
+	"assert":     ASSERT,
+	"reroll":     REROLL,
 }
 
 // LookupIdent checks if an identifier is a keyword
@@ -89,6 +182,33 @@ func LookupIdent(ident string) TokenType {
 	return IDENT
 }
 
+// isTypeToken reports whether t is one of the type keywords
+func isTypeToken(t TokenType) bool {
+	switch t {
+	case TYPE_INT, TYPE_UINT, TYPE_FLOAT, TYPE_UNOFLOAT, TYPE_BOOL, TYPE_STRING, TYPE_MAP, FUNC:
+		return true
+	}
+	return false
+}
+
+// isNumericTypeToken reports whether t is one of the numeric type keywords
+func isNumericTypeToken(t TokenType) bool {
+	switch t {
+	case TYPE_INT, TYPE_UINT, TYPE_FLOAT, TYPE_UNOFLOAT:
+		return true
+	}
+	return false
+}
+
+// isKeyTypeToken reports whether t is a type that can be used for map keys
+func isKeyTypeToken(t TokenType) bool {
+	switch t {
+	case TYPE_INT, TYPE_UINT, TYPE_FLOAT, TYPE_UNOFLOAT, TYPE_BOOL, TYPE_STRING:
+		return true
+	}
+	return false
+}
+
 // String returns a human-readable representation of the token
 func (t Token) String() string {
 	return string(t.Type) + ":" + t.Literal
@@ -0,0 +1,60 @@
+package interpreter
+
+import (
+	"errors"
+
+	"wtf-script/types"
+)
+
+// evalTryStmt runs the body and, when it fails with a runtime error, runs the
+// handler with the error bound to the catch parameter. Control flow signals
+// and errors that are not runtime errors, such as parse errors of an import,
+// are never caught.
+func (i *Interpreter) evalTryStmt(node *TryStmt) (any, error) {
+	result, err := i.Evaluate(node.Body)
+	if err == nil {
+		return result, nil
+	}
+
+	var rtErr *RuntimeError
+	if !errors.As(err, &rtErr) {
+		return nil, err
+	}
+
+	scope := NewEnvironment(i.env)
+	if node.Param != nil {
+		scope.Define(node.Param.Value, types.Variable{Type: types.Struct, Value: newErrorValue(rtErr)})
+	}
+	return i.evalInScope(scope, func() (any, error) {
+		return i.Evaluate(node.Handler)
+	})
+}
+
+func (i *Interpreter) evalThrowStmt(node *ThrowStmt) (any, error) {
+	val, err := i.Evaluate(node.Value)
+	if err != nil {
+		return nil, err
+	}
+
+	msg, ok := val.(string)
+	if !ok {
+		return nil, NewTypeMismatchErrorf(node.Token.Position(), "throw expects a string, got %s", getTypeString(val))
+	}
+	return nil, NewThrownError(node.Token.Position(), msg)
+}
+
+// newErrorValue exposes a runtime error to scripts as a read-only Error struct
+func newErrorValue(err *RuntimeError) *types.StructValue {
+	pos := err.Position
+	if pos == nil {
+		pos = &Position{}
+	}
+
+	value := types.NewStructValue(ErrorTypeName)
+	value.Set("message", err.Msg)
+	value.Set("kind", string(err.Kind))
+	value.Set("line", int64(pos.Line))
+	value.Set("column", int64(pos.Column))
+	value.Set("file", pos.File)
+	return value
+}
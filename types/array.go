@@ -0,0 +1,18 @@
+package types
+
+import "strings"
+
+// ArrayValue is the runtime value of an array. Arrays are reference values:
+// assigning an array to a variable of the same element type shares it.
+type ArrayValue struct {
+	ElemType VarType
+	Elements []any
+}
+
+func (a *ArrayValue) String() string {
+	elems := make([]string, len(a.Elements))
+	for i, e := range a.Elements {
+		elems[i] = FormatValue(e)
+	}
+	return "[" + strings.Join(elems, ", ") + "]"
+}
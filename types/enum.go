@@ -0,0 +1,14 @@
+package types
+
+// EnumValue is the runtime value of an enum member. Unlike structs, members are plain
+// values: two members are equal when they belong to the same enum and have the same name.
+type EnumValue struct {
+	TypeName string
+	Module   string // path of the module that declares the enum, empty for the main program
+	Member   string
+}
+
+// String returns the name of the member, which is how print shows it
+func (e EnumValue) String() string {
+	return e.Member
+}
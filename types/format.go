@@ -0,0 +1,16 @@
+package types
+
+import "fmt"
+
+// FormatValue renders a value the way print shows it, floats keep six decimals.
+// Arrays, maps and structs format their elements with it as well.
+func FormatValue(v any) string {
+	switch val := v.(type) {
+	case float64:
+		return fmt.Sprintf("%f", val)
+	case UnofloatType:
+		return fmt.Sprintf("%f", float64(val))
+	default:
+		return fmt.Sprintf("%v", val)
+	}
+}
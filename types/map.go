@@ -0,0 +1,64 @@
+package types
+
+import "strings"
+
+// MapValue is the runtime value of a map. Keys are kept in insertion order, so
+// iterating a map always visits its entries in the same order for a given seed.
+// Like arrays, maps are reference values.
+type MapValue struct {
+	KeyType   VarType
+	ValueType VarType
+	keys      []any
+	entries   map[any]any
+}
+
+func NewMapValue(keyType, valueType VarType) *MapValue {
+	return &MapValue{
+		KeyType:   keyType,
+		ValueType: valueType,
+		entries:   make(map[any]any),
+	}
+}
+
+// Get returns the value stored for key
+func (m *MapValue) Get(key any) (any, bool) {
+	val, ok := m.entries[key]
+	return val, ok
+}
+
+// Set stores a value for key, appending the key if it is new
+func (m *MapValue) Set(key, value any) {
+	if _, ok := m.entries[key]; !ok {
+		m.keys = append(m.keys, key)
+	}
+	m.entries[key] = value
+}
+
+// Has reports whether key is present
+func (m *MapValue) Has(key any) bool {
+	_, ok := m.entries[key]
+	return ok
+}
+
+// Keys returns a copy of the keys in insertion order
+func (m *MapValue) Keys() []any {
+	return append([]any(nil), m.keys...)
+}
+
+// Len returns the number of entries
+func (m *MapValue) Len() int {
+	return len(m.keys)
+}
+
+// TypeString returns the type of the map, e.g. map[string]int
+func (m *MapValue) TypeString() string {
+	return "map[" + m.KeyType.String() + "]" + m.ValueType.String()
+}
+
+func (m *MapValue) String() string {
+	pairs := make([]string, len(m.keys))
+	for i, k := range m.keys {
+		pairs[i] = FormatValue(k) + ": " + FormatValue(m.entries[k])
+	}
+	return "{" + strings.Join(pairs, ", ") + "}"
+}
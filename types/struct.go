@@ -0,0 +1,46 @@
+package types
+
+import "strings"
+
+// StructValue is the runtime value of a struct instance. Fields keep their
+// declaration order. Like arrays and maps, structs are reference values.
+type StructValue struct {
+	TypeName string
+	Module   string // path of the module that declares the type, empty for the main program
+	fields   []string
+	values   map[string]any
+}
+
+func NewStructValue(typeName string) *StructValue {
+	return &StructValue{
+		TypeName: typeName,
+		values:   make(map[string]any),
+	}
+}
+
+// Get returns the value of a field
+func (s *StructValue) Get(field string) (any, bool) {
+	val, ok := s.values[field]
+	return val, ok
+}
+
+// Set updates a field, appending it if it is new
+func (s *StructValue) Set(field string, value any) {
+	if _, ok := s.values[field]; !ok {
+		s.fields = append(s.fields, field)
+	}
+	s.values[field] = value
+}
+
+// Fields returns the field names in declaration order
+func (s *StructValue) Fields() []string {
+	return append([]string(nil), s.fields...)
+}
+
+func (s *StructValue) String() string {
+	fields := make([]string, len(s.fields))
+	for i, f := range s.fields {
+		fields[i] = f + ": " + FormatValue(s.values[f])
+	}
+	return s.TypeName + "{" + strings.Join(fields, ", ") + "}"
+}
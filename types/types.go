@@ -9,6 +9,17 @@ type IInterpreter interface {
 	GenerateRandomString(n int, charset string) string
 	SetSeed(seed int64)
 	LogError(format string, args ...any)
+	CallFunction(fn any, args []any) (any, error)
+}
+
+// Callable is implemented by function values, which builtins can invoke through IInterpreter.CallFunction
+type Callable interface {
+	Arity() int
+}
+
+// Namespace is implemented by imported modules, whose members are accessed as name.member
+type Namespace interface {
+	Lookup(name string) (any, bool)
 }
 
 type IBuiltinFunc func(args []any, i IInterpreter) any
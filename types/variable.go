@@ -1,8 +1,15 @@
 package types
 
 type Variable struct {
-	Type  VarType
-	Value any
+	Type     VarType
+	TypeName string // the named type the variable was declared with, e.g. Dice, empty for plain types
+	Value    any
+	Const    bool // declared with const, the variable cannot be reassigned
+	Volatile bool // declared with volatile, every read draws a fresh value from Generator
+
+	// Generator draws a fresh value the way the declaration did, so that reroll can draw again.
+	// It is nil for variables that were not drawn at random, such as int x = 5;
+	Generator func() (any, error)
 }
 
 type VarType int
@@ -17,6 +24,12 @@ const (
 	Unofloat // unofloat
 	Bool
 	String
+	Func
+	Array
+	Map
+	Struct
+	Module
+	Enum
 	Unknown
 )
 
@@ -34,6 +47,18 @@ func (t VarType) String() string {
 		return "bool"
 	case String:
 		return "string"
+	case Func:
+		return "func"
+	case Array:
+		return "array"
+	case Map:
+		return "map"
+	case Struct:
+		return "struct"
+	case Module:
+		return "module"
+	case Enum:
+		return "enum"
 	default:
 		return "unknown"
 	}